@@ -0,0 +1,53 @@
+// Command daogen generates Go types from a sample JSON or HJSON document.
+//
+//	daogen -type Config -pkg config -o config_types.go config.hjson
+//
+// Without a file the sample is read from stdin and without -o the source is
+// written to stdout. It is meant to be used from go:generate:
+//
+//	//go:generate go run github.com/hyprstereo/go-dao/cmd/daogen -type Config -o config_types.go config.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	godao "github.com/hyprstereo/go-dao"
+)
+
+func main() {
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name, defaults to $GOPACKAGE or main")
+	name := flag.String("type", "Root", "name of the root type")
+	tag := flag.String("tag", "json", "struct tag key")
+	out := flag.String("o", "", "output file, defaults to stdout")
+	flag.Parse()
+
+	if err := run(*pkg, *name, *tag, *out, flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, "daogen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(pkg, name, tag, out, in string) (err error) {
+	var data []byte
+	if in == "" || in == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(in)
+	}
+	if err != nil {
+		return
+	}
+	src, err := godao.GenerateGo(data, godao.GenerateOptions{Package: pkg, Name: name, Tag: tag})
+	if err != nil {
+		return
+	}
+	src = append([]byte("// Code generated by daogen; DO NOT EDIT.\n\n"), src...)
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return
+	}
+	return os.WriteFile(out, src, 0644)
+}
@@ -0,0 +1,418 @@
+package godao
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
+
+// CoerceMode controls how typed accessors convert stored values.
+type CoerceMode uint8
+
+const (
+	// CoerceLenient converts between kinds, ie: "42" -> 42, "1.5s" -> 1.5s, 1 -> true.
+	CoerceLenient CoerceMode = iota
+	// CoerceStrict only accepts values already of the target kind, plus the
+	// JSON form of time types (RFC 3339 strings and nanosecond durations).
+	CoerceStrict
+)
+
+var (
+	ErrNotFound     = errors.New("path not found")
+	ErrInvalidValue = errors.New("invalid value")
+)
+
+// PathError reports a failed typed access at Path.
+type PathError struct {
+	Path  string
+	Type  string
+	Value any
+	Err   error
+}
+
+func (e *PathError) Error() string {
+	if errors.Is(e.Err, ErrNotFound) {
+		return fmt.Sprintf("%q: %s", e.Path, e.Err)
+	}
+	if errors.Is(e.Err, ErrUnknownField) {
+		return fmt.Sprintf("%q: %s in %s", e.Path, e.Err, e.Type)
+	}
+	return fmt.Sprintf("%q: cannot convert %T(%v) to %s: %s", e.Path, e.Value, e.Value, e.Type, e.Err)
+}
+
+func (e *PathError) Unwrap() error {
+	return e.Err
+}
+
+func coerceMode(mode []CoerceMode) CoerceMode {
+	if len(mode) > 0 {
+		return mode[0]
+	}
+	return CoerceLenient
+}
+
+func toInt64(v any, mode CoerceMode) (int64, error) {
+	switch val := v.(type) {
+	case int:
+		return int64(val), nil
+	case int8:
+		return int64(val), nil
+	case int16:
+		return int64(val), nil
+	case int32:
+		return int64(val), nil
+	case int64:
+		return val, nil
+	case uint:
+		return uintToInt64(uint64(val))
+	case uint8:
+		return int64(val), nil
+	case uint16:
+		return int64(val), nil
+	case uint32:
+		return int64(val), nil
+	case uint64:
+		return uintToInt64(val)
+	case time.Duration:
+		return int64(val), nil
+	case float32:
+		return floatToInt64(float64(val))
+	case float64:
+		return floatToInt64(val)
+	}
+	if mode == CoerceStrict {
+		return 0, ErrInvalidValue
+	}
+	switch val := v.(type) {
+	case string:
+		s := strings.TrimSpace(val)
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, ErrInvalidValue
+		}
+		return floatToInt64(f)
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return 0, ErrInvalidValue
+}
+
+func uintToInt64(u uint64) (int64, error) {
+	if u > math.MaxInt64 {
+		return 0, errors.New("value overflows int64")
+	}
+	return int64(u), nil
+}
+
+func floatToInt64(f float64) (int64, error) {
+	if f != math.Trunc(f) {
+		return 0, errors.New("value has a fraction")
+	}
+	if f < math.MinInt64 || f >= math.MaxInt64 {
+		return 0, errors.New("value overflows int64")
+	}
+	return int64(f), nil
+}
+
+func toFloat64(v any, mode CoerceMode) (float64, error) {
+	switch val := v.(type) {
+	case float64:
+		return val, nil
+	case float32:
+		return float64(val), nil
+	case uint64:
+		return float64(val), nil
+	case uint:
+		return float64(val), nil
+	}
+	if _, isString := v.(string); !isString || mode != CoerceStrict {
+		if n, err := toInt64(v, mode); err == nil {
+			return float64(n), nil
+		}
+	}
+	if s, ok := v.(string); ok && mode == CoerceLenient {
+		if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+			return f, nil
+		}
+	}
+	return 0, ErrInvalidValue
+}
+
+func toString(v any, mode CoerceMode) (string, error) {
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case []byte:
+		if mode == CoerceLenient {
+			return string(val), nil
+		}
+	case nil:
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		if mode == CoerceLenient {
+			return fmt.Sprint(val), nil
+		}
+	case float32, float64:
+		if mode == CoerceLenient {
+			f, _ := toFloat64(val, mode)
+			return strconv.FormatFloat(f, 'f', -1, 64), nil
+		}
+	case fmt.Stringer:
+		if mode == CoerceLenient {
+			return val.String(), nil
+		}
+	}
+	return "", ErrInvalidValue
+}
+
+func toBool(v any, mode CoerceMode) (bool, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	if mode == CoerceStrict {
+		return false, ErrInvalidValue
+	}
+	if s, ok := v.(string); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return false, ErrInvalidValue
+		}
+		return b, nil
+	}
+	f, err := toFloat64(v, mode)
+	return f != 0, err
+}
+
+func toDuration(v any, mode CoerceMode) (time.Duration, error) {
+	switch val := v.(type) {
+	case time.Duration:
+		return val, nil
+	case string:
+		if mode == CoerceStrict {
+			return 0, ErrInvalidValue
+		}
+		if d, err := time.ParseDuration(strings.TrimSpace(val)); err == nil {
+			return d, nil
+		}
+	}
+	n, err := toInt64(v, mode)
+	return time.Duration(n), err
+}
+
+// timeLayouts are tried in order by lenient time coercion.
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+func toTime(v any, mode CoerceMode) (time.Time, error) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, nil
+	case *time.Time:
+		if val != nil {
+			return *val, nil
+		}
+	case string:
+		s := strings.TrimSpace(val)
+		if mode == CoerceStrict {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return t, ErrInvalidValue
+			}
+			return t, nil
+		}
+		for _, layout := range timeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+	default:
+		if mode == CoerceLenient {
+			if n, err := toInt64(v, mode); err == nil {
+				return time.Unix(n, 0), nil
+			}
+		}
+	}
+	return time.Time{}, ErrInvalidValue
+}
+
+func toStringSlice(v any, mode CoerceMode) ([]string, error) {
+	if s, ok := v.([]string); ok {
+		return s, nil
+	}
+	arr, ok := asArray(v)
+	if !ok {
+		if s, isString := v.(string); isString && mode == CoerceLenient {
+			return []string{s}, nil
+		}
+		return nil, ErrInvalidValue
+	}
+	out := make([]string, len(arr))
+	for x, e := range arr {
+		s, err := toString(e, mode)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", x, err)
+		}
+		out[x] = s
+	}
+	return out, nil
+}
+
+func toMap(v any, mode CoerceMode) (Map, error) {
+	if obj, ok := asObject(v); ok {
+		return Map(obj), nil
+	}
+	if mode == CoerceLenient && v != nil {
+		if obj, ok := asObject(toGeneric(v)); ok {
+			return Map(obj), nil
+		}
+	}
+	return nil, ErrInvalidValue
+}
+
+// coerce converts v into T, trying the typed converters before a direct
+// assertion and, in lenient mode, a JSON round-trip.
+func coerce[T any](v any, mode CoerceMode) (out T, err error) {
+	var res any
+	switch any(out).(type) {
+	case int:
+		var n int64
+		n, err = toInt64(v, mode)
+		if n > math.MaxInt || n < math.MinInt {
+			err = errors.New("value overflows int")
+		}
+		res = int(n)
+	case int64:
+		res, err = toInt64(v, mode)
+	case float64:
+		res, err = toFloat64(v, mode)
+	case string:
+		res, err = toString(v, mode)
+	case bool:
+		res, err = toBool(v, mode)
+	case time.Duration:
+		res, err = toDuration(v, mode)
+	case time.Time:
+		res, err = toTime(v, mode)
+	case []string:
+		res, err = toStringSlice(v, mode)
+	case Map:
+		res, err = toMap(v, mode)
+	case map[string]any:
+		var m Map
+		m, err = toMap(v, mode)
+		res = map[string]any(m)
+	default:
+		if t, ok := v.(T); ok {
+			return t, nil
+		}
+		if mode == CoerceStrict {
+			return out, ErrInvalidValue
+		}
+		if e := json.Decode(json.Encode(v), &out); e != nil {
+			err = e
+		}
+		return
+	}
+	if err == nil {
+		out = res.(T)
+	}
+	return
+}
+
+// GetAs resolves p and converts the value into T.
+func GetAs[T any](m Map, p string, mode ...CoerceMode) (T, error) {
+	return ResultAs[T](m.Get(p), mode...)
+}
+
+// ResultAs converts the value held by r into T.
+func ResultAs[T any](r Result, mode ...CoerceMode) (out T, err error) {
+	if !r.Exists() {
+		return out, &PathError{Path: r.path, Type: reflect.TypeOf(&out).Elem().String(), Err: ErrNotFound}
+	}
+	v := r.Value()
+	if out, err = coerce[T](v, coerceMode(mode)); err != nil {
+		err = &PathError{Path: r.path, Type: reflect.TypeOf(&out).Elem().String(), Value: v, Err: err}
+	}
+	return
+}
+
+func (r Result) Int64E(mode ...CoerceMode) (int64, error) {
+	return ResultAs[int64](r, mode...)
+}
+
+func (r Result) Float64E(mode ...CoerceMode) (float64, error) {
+	return ResultAs[float64](r, mode...)
+}
+
+func (r Result) StringE(mode ...CoerceMode) (string, error) {
+	return ResultAs[string](r, mode...)
+}
+
+func (r Result) BoolE(mode ...CoerceMode) (bool, error) {
+	return ResultAs[bool](r, mode...)
+}
+
+func (r Result) DurationE(mode ...CoerceMode) (time.Duration, error) {
+	return ResultAs[time.Duration](r, mode...)
+}
+
+func (r Result) TimeE(mode ...CoerceMode) (time.Time, error) {
+	return ResultAs[time.Time](r, mode...)
+}
+
+func (r Result) StringSliceE(mode ...CoerceMode) ([]string, error) {
+	return ResultAs[[]string](r, mode...)
+}
+
+func (r Result) MapE(mode ...CoerceMode) (Map, error) {
+	return ResultAs[Map](r, mode...)
+}
+
+func (m Map) Int64E(p string, mode ...CoerceMode) (int64, error) {
+	return GetAs[int64](m, p, mode...)
+}
+
+func (m Map) Float64E(p string, mode ...CoerceMode) (float64, error) {
+	return GetAs[float64](m, p, mode...)
+}
+
+func (m Map) StringE(p string, mode ...CoerceMode) (string, error) {
+	return GetAs[string](m, p, mode...)
+}
+
+func (m Map) BoolE(p string, mode ...CoerceMode) (bool, error) {
+	return GetAs[bool](m, p, mode...)
+}
+
+func (m Map) DurationE(p string, mode ...CoerceMode) (time.Duration, error) {
+	return GetAs[time.Duration](m, p, mode...)
+}
+
+func (m Map) TimeE(p string, mode ...CoerceMode) (time.Time, error) {
+	return GetAs[time.Time](m, p, mode...)
+}
+
+func (m Map) StringSliceE(p string, mode ...CoerceMode) ([]string, error) {
+	return GetAs[[]string](m, p, mode...)
+}
+
+func (m Map) MapE(p string, mode ...CoerceMode) (Map, error) {
+	return GetAs[Map](m, p, mode...)
+}
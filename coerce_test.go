@@ -0,0 +1,60 @@
+package godao
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypedAccessors(t *testing.T) {
+	m := Map{
+		"port":    "8080",
+		"ratio":   0.5,
+		"timeout": "1.5s",
+		"hosts":   []any{"a", "b"},
+		"db":      Map{"user": "root"},
+		"since":   "2022-03-01T10:00:00Z",
+	}
+
+	if n, err := m.Int64E("port"); err != nil || n != 8080 {
+		t.Errorf("Int64E = %v, %v", n, err)
+	}
+	if _, err := m.Int64E("port", CoerceStrict); !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("strict Int64E error = %v", err)
+	}
+	if d, err := m.DurationE("timeout"); err != nil || d != 1500*time.Millisecond {
+		t.Errorf("DurationE = %v, %v", d, err)
+	}
+	if ts, err := m.TimeE("since", CoerceStrict); err != nil || ts.Year() != 2022 {
+		t.Errorf("TimeE = %v, %v", ts, err)
+	}
+	if s, err := m.StringSliceE("hosts"); err != nil || !reflect.DeepEqual(s, []string{"a", "b"}) {
+		t.Errorf("StringSliceE = %v, %v", s, err)
+	}
+	if db, err := m.MapE("db"); err != nil || db["user"] != "root" {
+		t.Errorf("MapE = %v, %v", db, err)
+	}
+	if f, err := GetAs[float64](m, "ratio"); err != nil || f != 0.5 {
+		t.Errorf("GetAs[float64] = %v, %v", f, err)
+	}
+	if _, err := GetAs[int](m, "ratio"); err == nil {
+		t.Error("fractional value converted to int")
+	}
+
+	_, err := m.Int64E("db.missing")
+	var pe *PathError
+	if !errors.As(err, &pe) || pe.Path != "db.missing" || !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing path error = %v", err)
+	}
+
+	_, err = m.Get("db").Get("missing").Int64E()
+	if !errors.As(err, &pe) || pe.Path != "db.missing" {
+		t.Errorf("nested Get error = %v", err)
+	}
+
+	var user string
+	if err := m.Decode("db.user", &user); err != nil || user != "root" {
+		t.Errorf("Decode = %q, %v", user, err)
+	}
+}
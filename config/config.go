@@ -0,0 +1,390 @@
+// Package config builds a configuration from layers merged on top of each
+// other: defaults, JSON/HJSON/YAML/TOML files, .env files, environment
+// variables and command-line flags. It remembers which layer supplied each
+// value, see Config.Source and Config.Explain.
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	godao "github.com/hyprstereo/go-dao"
+	"github.com/hyprstereo/go-dao/encoding/hjson"
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/spf13/afero"
+)
+
+// Options configures a Config.
+type Options struct {
+	// Fs holds the files, defaults to the OS file system.
+	Fs afero.Fs
+	// Environ returns the environment variables as "KEY=value" strings,
+	// defaults to os.Environ.
+	Environ func() []string
+}
+
+type origin struct {
+	layer int
+	desc  string
+}
+
+// Config is the result of the layers added so far, each layer overriding the
+// values of the previous ones. It is not safe to add layers concurrently.
+type Config struct {
+	opt     Options
+	data    godao.Map
+	sources map[string]origin // leaf path -> layer that set it
+	layers  int
+}
+
+// New creates an empty Config.
+func New(opts ...Options) *Config {
+	c := &Config{data: godao.Map{}, sources: map[string]origin{}}
+	if len(opts) > 0 {
+		c.opt = opts[0]
+	}
+	if c.opt.Fs == nil {
+		c.opt.Fs = afero.NewOsFs()
+	}
+	if c.opt.Environ == nil {
+		c.opt.Environ = os.Environ
+	}
+	return c
+}
+
+// Layers lists the layers applied by Load, in precedence order.
+type Layers struct {
+	Defaults godao.Map
+	// Files are JSON, HJSON, YAML or TOML files, chosen by extension.
+	Files []string
+	// DotEnv are .env files, read like environment variables.
+	DotEnv []string
+	// EnvPrefix selects the environment variables, see Config.Env. Empty
+	// skips the environment.
+	EnvPrefix string
+	// Flags are the flags of a parsed FlagSet, see Config.Flags.
+	Flags *flag.FlagSet
+	// Optional skips the missing files.
+	Optional bool
+}
+
+// Load applies defaults, files, .env files, environment variables and flags,
+// in that order.
+func Load(l Layers, opts ...Options) (c *Config, err error) {
+	c = New(opts...)
+	if l.Defaults != nil {
+		c.Defaults(l.Defaults)
+	}
+	for _, f := range l.Files {
+		if err = c.File(f, l.Optional); err != nil {
+			return
+		}
+	}
+	for _, f := range l.DotEnv {
+		if err = c.DotEnv(f, l.EnvPrefix, l.Optional); err != nil {
+			return
+		}
+	}
+	if l.EnvPrefix != "" {
+		if err = c.Env(l.EnvPrefix); err != nil {
+			return
+		}
+	}
+	if l.Flags != nil {
+		c.Flags(l.Flags)
+	}
+	return
+}
+
+// Defaults adds m as a layer.
+func (c *Config) Defaults(m godao.Map) {
+	c.add(m, func(string) string { return "defaults" })
+}
+
+// File adds a JSON, HJSON, YAML or TOML file as a layer, the format being
+// chosen by extension. With optional set, a missing file is skipped.
+func (c *Config) File(path string, optional ...bool) (err error) {
+	data, err := afero.ReadFile(c.opt.Fs, path)
+	if err != nil {
+		if os.IsNotExist(err) && len(optional) > 0 && optional[0] {
+			err = nil
+		}
+		return
+	}
+	var m godao.Map
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		m, err = godao.Map{}.FromYAML(data)
+	case ".toml":
+		m, err = godao.Map{}.FromTOML(data)
+	default:
+		err = hjson.Unmarshal(data, &m)
+	}
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	desc := "file:" + path
+	c.add(m, func(string) string { return desc })
+	return
+}
+
+// Env adds the environment variables starting with prefix as a layer. The
+// prefix is removed, the names are lowercased and "__" nests them, ie:
+// APP_DB__MAX_CONNS with prefix "APP_" sets "db.max_conns". Values are
+// typed like in .env files, see DotEnv. A variable nesting under another one
+// replaces it, ie: APP_DB__PORT wins over APP_DB, and variables mixing
+// indexes and names under the same parent fail.
+func (c *Config) Env(prefix string) (err error) {
+	vars := map[string]any{}
+	for _, kv := range c.opt.Environ() {
+		if k, v, ok := strings.Cut(kv, "="); ok {
+			vars[k] = typed(v)
+		}
+	}
+	if err = c.addVars(vars, prefix, "env:"); err != nil {
+		return fmt.Errorf("config: env: %w", err)
+	}
+	return
+}
+
+// DotEnv adds a .env file as a layer, read like Env. Lines are KEY=value,
+// optionally prefixed with "export". Values can be double quoted, with
+// escapes, or single quoted; "#" starts a comment outside quotes. Unquoted
+// values are typed: booleans, numbers, and JSON arrays or objects. With
+// optional set, a missing file is skipped.
+func (c *Config) DotEnv(path, prefix string, optional ...bool) (err error) {
+	data, err := afero.ReadFile(c.opt.Fs, path)
+	if err != nil {
+		if os.IsNotExist(err) && len(optional) > 0 && optional[0] {
+			err = nil
+		}
+		return
+	}
+	vars, err := parseDotEnv(data)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	if err = c.addVars(vars, prefix, "dotenv:"+path+":"); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return
+}
+
+func parseDotEnv(data []byte) (vars map[string]any, err error) {
+	vars = map[string]any{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		s = strings.TrimPrefix(s, "export ")
+		k, v, ok := strings.Cut(s, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" || strings.ContainsAny(k, " \t") {
+			return nil, fmt.Errorf("line %d: expected KEY=value", line)
+		}
+		v = strings.TrimSpace(v)
+		switch {
+		case strings.HasPrefix(v, `"`):
+			end := closingQuote(v)
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", line)
+			}
+			if vars[k], err = strconv.Unquote(v[:end+1]); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+		case strings.HasPrefix(v, "'"):
+			end := strings.IndexByte(v[1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value", line)
+			}
+			vars[k] = v[1 : end+1]
+		default:
+			if x := strings.Index(v, " #"); x >= 0 {
+				v = strings.TrimSpace(v[:x])
+			}
+			vars[k] = typed(v)
+		}
+	}
+	return vars, sc.Err()
+}
+
+func closingQuote(s string) int {
+	for x := 1; x < len(s); x++ {
+		switch s[x] {
+		case '\\':
+			x++
+		case '"':
+			return x
+		}
+	}
+	return -1
+}
+
+// addVars adds the variables starting with prefix, in sorted order so that
+// nested variables replace their parents.
+func (c *Config) addVars(vars map[string]any, prefix, desc string) (err error) {
+	m := godao.Map{}
+	names := map[string]string{}
+	keys := godao.MapKeys(vars)
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !strings.HasPrefix(k, prefix) || len(k) == len(prefix) {
+			continue
+		}
+		parts := strings.Split(strings.ToLower(k[len(prefix):]), "__")
+		for x, p := range parts {
+			parts[x] = godao.EscapePathKey(p)
+		}
+		path := strings.Join(parts, ".")
+		if m.Set(path, vars[k]) == nil {
+			return fmt.Errorf("%s conflicts with another variable", k)
+		}
+		names[path] = k
+	}
+	c.add(m, func(path string) string { return desc + names[path] })
+	return
+}
+
+// typed converts an unquoted value into a boolean, a number or JSON.
+func typed(s string) any {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	// leading zeros are kept, ie: zip codes
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN_") {
+		return f
+	}
+	if strings.HasPrefix(s, "[") || strings.HasPrefix(s, "{") {
+		var v any
+		if json.Decode([]byte(s), &v) == nil {
+			return v
+		}
+	}
+	return s
+}
+
+// Flags adds the flags set on the command line as a layer, flags left to
+// their default are ignored. Flag names are paths, ie: -db.port=5432.
+func (c *Config) Flags(fs *flag.FlagSet) {
+	m := godao.Map{}
+	fs.Visit(func(f *flag.Flag) {
+		if g, ok := f.Value.(flag.Getter); ok {
+			m.Set(f.Name, g.Get())
+		} else {
+			m.Set(f.Name, f.Value.String())
+		}
+	})
+	c.add(m, func(path string) string { return "flag:-" + path })
+}
+
+// add merges m and records the origin of its leaves.
+func (c *Config) add(m godao.Map, desc func(path string) string) {
+	layer := c.layers
+	c.layers++
+	leaves("", m, func(path string) {
+		for p := range c.sources {
+			if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".") {
+				delete(c.sources, p)
+			}
+		}
+		c.sources[path] = origin{layer: layer, desc: desc(path)}
+	})
+	c.data.MergeDeep(m)
+}
+
+// leaves calls fn with the paths of the values that are not objects.
+func leaves(prefix string, v any, fn func(path string)) {
+	obj, ok := object(v)
+	if !ok || (len(obj) == 0 && prefix != "") {
+		if prefix != "" {
+			fn(prefix)
+		}
+		return
+	}
+	for k, e := range obj {
+		p := godao.EscapePathKey(k)
+		if prefix != "" {
+			p = prefix + "." + p
+		}
+		leaves(p, e, fn)
+	}
+}
+
+func object(v any) (map[string]any, bool) {
+	switch val := v.(type) {
+	case godao.Map:
+		return val, true
+	case map[string]any:
+		return val, true
+	}
+	return nil, false
+}
+
+// Map returns a copy of the effective configuration.
+func (c *Config) Map() godao.Map {
+	return c.data.Clone()
+}
+
+// Get returns the effective value at path, see godao.Map.Get.
+func (c *Config) Get(path string, defaultValue ...any) godao.Result {
+	return c.data.Get(path, defaultValue...)
+}
+
+// Has reports whether path is set.
+func (c *Config) Has(path string) bool {
+	return c.data.Get(path).Exists()
+}
+
+// Source returns the layer that supplied the value at path, ie: "defaults",
+// "file:app.json", "dotenv:.env:APP_PORT", "env:APP_PORT" or "flag:-port".
+// For an object, it is the last layer that set a value inside it. It is
+// empty when path is not set.
+func (c *Config) Source(path string) string {
+	if o, ok := c.sources[path]; ok {
+		return o.desc
+	}
+	best := origin{layer: -1}
+	for p, o := range c.sources {
+		if (strings.HasPrefix(p, path+".") || strings.HasPrefix(path, p+".")) && o.layer > best.layer {
+			best = o
+		}
+	}
+	if best.layer < 0 || !c.data.Get(path).Exists() {
+		return ""
+	}
+	return best.desc
+}
+
+// Explain lists the effective values, one "path = value (source)" line per
+// value, sorted by path.
+func (c *Config) Explain() string {
+	paths := make([]string, 0, len(c.sources))
+	for p := range c.sources {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	for _, p := range paths {
+		fmt.Fprintf(&b, "%s = %s (%s)\n", p, string(json.Encode(c.data.Get(p).Value())), c.sources[p].desc)
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	godao "github.com/hyprstereo/go-dao"
+	"github.com/spf13/afero"
+)
+
+func TestLoad(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/app.hjson", []byte("{\n  db: {\n    host: db.local\n    port: 5432\n  }\n  tags: [\"a\", \"b\"]\n}"), 0644)
+	afero.WriteFile(fs, "/app.yaml", []byte("db:\n  user: svc\n"), 0644)
+	afero.WriteFile(fs, "/.env", []byte("# comment\nexport APP_DB__PASSWORD=\"s3 #cret\"\nAPP_LOG__LEVEL=debug # inline\nAPP_ZIP='0123'\n"), 0644)
+	env := []string{"APP_DB__PORT=6543", "APP_DEBUG=true", "OTHER=1"}
+	flags := flag.NewFlagSet("app", flag.ContinueOnError)
+	flags.String("log.level", "info", "")
+	flags.Int("db.pool", 4, "")
+	flags.Parse([]string{"-log.level=warn"})
+
+	c, err := Load(Layers{
+		Defaults:  godao.Map{"db": godao.Map{"host": "localhost", "port": 5432, "pool": 10}, "log": godao.Map{"level": "info"}},
+		Files:     []string{"/app.hjson", "/app.yaml", "/missing.json"},
+		DotEnv:    []string{"/.env"},
+		EnvPrefix: "APP_",
+		Flags:     flags,
+		Optional:  true,
+	}, Options{Fs: fs, Environ: func() []string { return env }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"db.host":     "db.local",
+		"db.port":     "6543",
+		"db.pool":     "10",
+		"db.user":     "svc",
+		"db.password": "s3 #cret",
+		"log.level":   "warn",
+		"zip":         "0123",
+		"debug":       "true",
+		"tags.1":      "b",
+	} {
+		if got := c.Get(path).String(); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	if c.Get("db.port").Value() != int64(6543) || c.Has("other") {
+		t.Errorf("env typing or prefix filtering failed: %v", c.Map())
+	}
+	for path, want := range map[string]string{
+		"db.host":   "file:/app.hjson",
+		"db.port":   "env:APP_DB__PORT",
+		"db.pool":   "defaults",
+		"log.level": "flag:-log.level",
+		"zip":       "dotenv:/.env:APP_ZIP",
+		"db":        "env:APP_DB__PORT",
+		"tags.0":    "file:/app.hjson",
+		"nope":      "",
+	} {
+		if got := c.Source(path); got != want {
+			t.Errorf("Source(%s) = %q, want %q", path, got, want)
+		}
+	}
+	if ex := c.Explain(); !strings.Contains(ex, "db.port = 6543 (env:APP_DB__PORT)\n") || !strings.HasPrefix(ex, "db.host =") {
+		t.Errorf("Explain =\n%s", ex)
+	}
+
+	afero.WriteFile(fs, "/bad.env", []byte("NOEQUALS\n"), 0644)
+	if err = New(Options{Fs: fs}).DotEnv("/bad.env", ""); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("bad .env = %v", err)
+	}
+	if err = New(Options{Fs: fs}).File("/missing.json"); err == nil {
+		t.Error("missing required file should fail")
+	}
+}
+
+func TestEnvConflicts(t *testing.T) {
+	env := []string{"APP_DB__PORT=1", "APP_DB=x", "APP_K|V@1=y"}
+	c := New(Options{Environ: func() []string { return env }})
+	if err := c.Env("APP_"); err != nil {
+		t.Fatal(err)
+	}
+	if c.Get("db.port").Int() != 1 || c.Get(`k\|v\@1`).String() != "y" || c.Source(`k\|v\@1`) != "env:APP_K|V@1" {
+		t.Errorf("env = %v", c.Map())
+	}
+	env = []string{"APP_L__0=a", "APP_L__X=b"}
+	if err := New(Options{Environ: func() []string { return env }}).Env("APP_"); err == nil || !strings.Contains(err.Error(), "APP_L__X") {
+		t.Errorf("mixed index and name = %v", err)
+	}
+}
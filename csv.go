@@ -0,0 +1,39 @@
+package godao
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyprstereo/go-dao/encoding/csv"
+)
+
+// MapsToCSV writes rows as CSV, see csv.Encode.
+func MapsToCSV(rows []Map, opts ...csv.Options) (data []byte, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	plain := make([]map[string]any, len(rows))
+	for x, r := range rows {
+		plain[x] = r
+	}
+	return csv.Encode(plain, opts...)
+}
+
+// MapsFromCSV reads every CSV row into a Map keyed by the header. Dotted
+// header names are expanded into nested Maps, so that rows written with path
+// columns read back into the same shape.
+func MapsFromCSV(data []byte, opts ...csv.Options) (rows []Map, err error) {
+	plain, err := csv.Decode(data, opts...)
+	rows = make([]Map, len(plain))
+	for x, r := range plain {
+		rows[x] = r
+		for k := range r {
+			if strings.Contains(k, ".") {
+				if rows[x], err = Unflatten(r, "."); err != nil {
+					return nil, fmt.Errorf("row %d: %w", x+1, err)
+				}
+				break
+			}
+		}
+	}
+	return
+}
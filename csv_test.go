@@ -0,0 +1,68 @@
+package godao
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyprstereo/go-dao/encoding/csv"
+)
+
+func TestCSV(t *testing.T) {
+	rows := []Map{
+		{"id": 1, "name": "a, b", "tags": []any{"x"}},
+		{"id": 2, "note": `say "hi"`, "ok": true},
+	}
+	out, err := MapsToCSV(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "id,name,tags,note,ok\n1,\"a, b\",\"[\"\"x\"\"]\",,\n2,,,\"say \"\"hi\"\"\",true\n"
+	if string(out) != want {
+		t.Errorf("MapsToCSV =\n%s\nwant\n%s", out, want)
+	}
+
+	back, err := MapsFromCSV(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back[1], Map{"id": int64(2), "name": nil, "tags": nil, "note": `say "hi"`, "ok": true}) {
+		t.Errorf("MapsFromCSV = %v", back[1])
+	}
+
+	nested := []Map{{"user": Map{"name": "jo", "zip": "0123"}, "score": 1.5}}
+	out, _ = MapsToCSV(nested, csv.Options{Comma: '\t', Columns: []string{"user.name", "user.zip", "score"}, QuoteAll: true, Quote: '\''})
+	if want := "'user.name'\t'user.zip'\t'score'\n'jo'\t'0123'\t'1.5'\n"; string(out) != want {
+		t.Errorf("MapsToCSV(tsv) = %q, want %q", out, want)
+	}
+	back, err = MapsFromCSV(out, csv.Options{Comma: '\t', Quote: '\''})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if back[0].Get("user.zip").String() != "0123" || back[0].Get("score").Float() != 1.5 {
+		t.Errorf("MapsFromCSV(tsv) = %v", back)
+	}
+
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	w.Write(Map{"a": 1})
+	if err := w.Write(Map{"b": 2}); err == nil {
+		t.Error("unknown key should fail")
+	}
+
+	r := csv.NewReader(strings.NewReader("a,b\n1,\"multi\nline\"\n2\n"), csv.Options{Strings: true})
+	row, _ := r.Read()
+	if row["b"] != "multi\nline" {
+		t.Errorf("Read = %v", row)
+	}
+	if row, _ = r.Read(); row["a"] != "2" || row["b"] != nil {
+		t.Errorf("Read = %v", row)
+	}
+
+	_, err = MapsFromCSV([]byte("a\n\"x\n"))
+	var ce *csv.Error
+	if !errors.As(err, &ce) || ce.Line != 2 {
+		t.Errorf("error = %v", err)
+	}
+}
@@ -0,0 +1,388 @@
+package csv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/tidwall/gjson"
+)
+
+// Options configures the Reader and the Writer.
+type Options struct {
+	// Comma is the field delimiter, defaults to ','. Use '\t' for TSV.
+	Comma rune
+	// Quote is the quoting character, defaults to '"'.
+	Quote rune
+	// QuoteAll quotes every field when writing, instead of only those that
+	// need it.
+	QuoteAll bool
+	// CRLF ends the written lines with \r\n.
+	CRLF bool
+	// Columns are the gjson paths written as columns, in order. The header
+	// holds the paths themselves. When empty, the columns are the keys of
+	// the rows.
+	Columns []string
+	// NoHeader skips the header when writing. When reading, the first line
+	// is data and the keys are Columns, or "1", "2"... when not set.
+	NoHeader bool
+	// Strings disables type inference when reading.
+	Strings bool
+}
+
+func options(opts []Options) (o Options) {
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	if o.Quote == 0 {
+		o.Quote = '"'
+	}
+	return
+}
+
+// Error is a syntax error at Line, starting at 1.
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("csv: line %d: %s", e.Line, e.Msg)
+}
+
+// Encode writes rows with a header made of the union of their keys, in the
+// order they first appear with the keys of each row sorted, unless
+// Options.Columns is set.
+func Encode(rows []map[string]any, opts ...Options) (data []byte, err error) {
+	o := options(opts)
+	if len(o.Columns) == 0 {
+		o.Columns = Union(rows)
+	}
+	var buf bytes.Buffer
+	w := NewWriter(&buf, o)
+	for _, row := range rows {
+		if err = w.Write(row); err != nil {
+			return
+		}
+	}
+	err = w.Flush()
+	data = buf.Bytes()
+	return
+}
+
+// Union returns the keys of rows, in the order they first appear with the
+// keys of each row sorted.
+func Union(rows []map[string]any) (keys []string) {
+	seen := map[string]bool{}
+	for _, row := range rows {
+		rk := make([]string, 0, len(row))
+		for k := range row {
+			if !seen[k] {
+				rk = append(rk, k)
+				seen[k] = true
+			}
+		}
+		sort.Strings(rk)
+		keys = append(keys, rk...)
+	}
+	return
+}
+
+// Writer streams rows as CSV.
+type Writer struct {
+	w      *bufio.Writer
+	o      Options
+	header bool
+	plain  []bool          // columns that are plain keys of the row
+	keys   map[string]bool // columns taken from the first row
+}
+
+// NewWriter returns a Writer to w. Without Options.Columns the columns are
+// the sorted keys of the first row, later rows cannot add keys.
+func NewWriter(w io.Writer, opts ...Options) *Writer {
+	return &Writer{w: bufio.NewWriter(w), o: options(opts)}
+}
+
+func (w *Writer) Write(row map[string]any) (err error) {
+	if !w.header {
+		w.header = true
+		if len(w.o.Columns) == 0 {
+			w.o.Columns = Union([]map[string]any{row})
+			w.keys = map[string]bool{}
+			for _, k := range w.o.Columns {
+				w.keys[k] = true
+			}
+		}
+		w.plain = make([]bool, len(w.o.Columns))
+		for x, c := range w.o.Columns {
+			w.plain[x] = !strings.ContainsAny(c, ".*?|#@\\")
+		}
+		if !w.o.NoHeader {
+			if err = w.WriteRecord(w.o.Columns); err != nil {
+				return
+			}
+		}
+	}
+	if w.keys != nil {
+		for k := range row {
+			if !w.keys[k] {
+				return fmt.Errorf("csv: key %q is not a column", k)
+			}
+		}
+	}
+	var raw []byte
+	fields := make([]string, len(w.o.Columns))
+	for x, c := range w.o.Columns {
+		if v, ok := row[c]; ok || w.plain[x] {
+			fields[x] = format(v)
+			continue
+		}
+		if raw == nil {
+			raw = json.Encode(row)
+		}
+		if r := gjson.GetBytes(raw, c); r.Exists() {
+			fields[x] = format(r.Value())
+		}
+	}
+	return w.WriteRecord(fields)
+}
+
+func format(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return val.String()
+	}
+	return string(json.Encode(v))
+}
+
+// WriteRecord writes fields as a line, quoting them as needed.
+func (w *Writer) WriteRecord(fields []string) (err error) {
+	for x, f := range fields {
+		if x > 0 {
+			w.w.WriteRune(w.o.Comma)
+		}
+		if !w.o.QuoteAll && !w.needsQuotes(f) {
+			w.w.WriteString(f)
+			continue
+		}
+		w.w.WriteRune(w.o.Quote)
+		for _, r := range f {
+			if r == w.o.Quote {
+				w.w.WriteRune(r)
+			}
+			w.w.WriteRune(r)
+		}
+		w.w.WriteRune(w.o.Quote)
+	}
+	if w.o.CRLF {
+		_, err = w.w.WriteString("\r\n")
+	} else {
+		err = w.w.WriteByte('\n')
+	}
+	return
+}
+
+func (w *Writer) needsQuotes(f string) bool {
+	if f == "" {
+		return false
+	}
+	if f[0] == ' ' || f[0] == '\t' {
+		return true
+	}
+	return strings.ContainsRune(f, w.o.Comma) || strings.ContainsRune(f, w.o.Quote) || strings.ContainsAny(f, "\r\n")
+}
+
+// Flush writes the buffered lines to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// Decode reads every row of data.
+func Decode(data []byte, opts ...Options) (rows []map[string]any, err error) {
+	r := NewReader(bytes.NewReader(data), opts...)
+	for {
+		row, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+}
+
+// Reader streams CSV rows as maps keyed by the header.
+type Reader struct {
+	r      *bufio.Reader
+	o      Options
+	line   int
+	header []string
+}
+
+func NewReader(r io.Reader, opts ...Options) *Reader {
+	return &Reader{r: bufio.NewReader(r), o: options(opts)}
+}
+
+// Header returns the column names, reading the header line when needed.
+func (r *Reader) Header() ([]string, error) {
+	if r.header != nil || r.o.NoHeader {
+		return r.header, nil
+	}
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return nil, err
+	}
+	r.header = rec
+	return rec, nil
+}
+
+// Read returns the next row, or io.EOF at the end of the input. Empty
+// fields are nil and, unless Options.Strings is set, numbers and booleans
+// are converted.
+func (r *Reader) Read() (row map[string]any, err error) {
+	if _, err = r.Header(); err != nil {
+		return
+	}
+	rec, err := r.ReadRecord()
+	if err != nil {
+		return
+	}
+	if r.header == nil {
+		r.header = append([]string{}, r.o.Columns...)
+		for x := len(r.header); x < len(rec); x++ {
+			r.header = append(r.header, strconv.Itoa(x+1))
+		}
+	}
+	if len(rec) > len(r.header) {
+		return nil, &Error{Line: r.line, Msg: fmt.Sprintf("%d fields, the header has %d", len(rec), len(r.header))}
+	}
+	row = make(map[string]any, len(r.header))
+	for x, k := range r.header {
+		var v any
+		if x < len(rec) {
+			v = r.infer(rec[x])
+		}
+		row[k] = v
+	}
+	return
+}
+
+func (r *Reader) infer(s string) any {
+	if s == "" {
+		return nil
+	}
+	if r.o.Strings {
+		return s
+	}
+	switch s {
+	case "true", "TRUE", "True":
+		return true
+	case "false", "FALSE", "False":
+		return false
+	}
+	// leading zeros are kept, ie: zip codes
+	digits := strings.TrimPrefix(s, "-")
+	if len(digits) > 1 && digits[0] == '0' && digits[1] != '.' {
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && !strings.ContainsAny(s, "xXnN_") {
+		return f
+	}
+	return s
+}
+
+// ReadRecord returns the fields of the next line, skipping empty lines.
+func (r *Reader) ReadRecord() (fields []string, err error) {
+	for {
+		r.line++
+		fields, err = r.record()
+		if err != nil || len(fields) != 1 || fields[0] != "" {
+			return
+		}
+	}
+}
+
+func (r *Reader) record() (fields []string, err error) {
+	start := r.line
+	var f strings.Builder
+	quoted, started := false, false
+	for {
+		c, _, err := r.r.ReadRune()
+		if err == io.EOF {
+			if quoted {
+				return nil, &Error{Line: start, Msg: "unterminated quoted field"}
+			}
+			if !started {
+				return nil, io.EOF
+			}
+			return append(fields, f.String()), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		started = true
+		switch {
+		case c == utf8.RuneError:
+			f.WriteRune(c)
+		case quoted && c == r.o.Quote:
+			next, _, err := r.r.ReadRune()
+			if err == nil && next == r.o.Quote {
+				f.WriteRune(c)
+				continue
+			}
+			if err == nil {
+				r.r.UnreadRune()
+			}
+			quoted = false
+		case quoted:
+			if c == '\n' {
+				r.line++
+			}
+			f.WriteRune(c)
+		case c == r.o.Quote && f.Len() == 0:
+			quoted = true
+		case c == r.o.Comma:
+			fields = append(fields, f.String())
+			f.Reset()
+		case c == '\r':
+			if next, _, err := r.r.ReadRune(); err == nil && next != '\n' {
+				r.r.UnreadRune()
+			}
+			return append(fields, f.String()), nil
+		case c == '\n':
+			return append(fields, f.String()), nil
+		case c == r.o.Quote:
+			return nil, &Error{Line: r.line, Msg: "bare quote in unquoted field"}
+		default:
+			f.WriteRune(c)
+		}
+	}
+}
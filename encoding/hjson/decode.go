@@ -8,9 +8,25 @@ import (
 )
 
 type hjsonParser struct {
-	data []byte
-	at   int  // The index of the current character
-	ch   byte // The current character
+	data    []byte
+	at      int  // The index of the current character
+	ch      byte // The current character
+	ordered bool // decode objects as *Object
+}
+
+// Object is an object decoded by UnmarshalOrdered, Keys are in the order
+// they appear in the document.
+type Object struct {
+	Keys   []string
+	Values map[string]interface{}
+}
+
+func (o *Object) OrderedKeys() []string {
+	return o.Keys
+}
+
+func (o *Object) OrderedValue(key string) interface{} {
+	return o.Values[key]
 }
 
 func (p *hjsonParser) resetAt() {
@@ -349,6 +365,13 @@ func (p *hjsonParser) readObject(withoutBraces bool) (value interface{}, err err
 	// Parse an object value.
 
 	object := make(map[string]interface{})
+	var keys []string
+	result := func() interface{} {
+		if p.ordered {
+			return &Object{Keys: keys, Values: object}
+		}
+		return object
+	}
 
 	if !withoutBraces {
 		// assuming ch == '{'
@@ -358,7 +381,7 @@ func (p *hjsonParser) readObject(withoutBraces bool) (value interface{}, err err
 	p.white()
 	if p.ch == '}' && !withoutBraces {
 		p.next()
-		return object, nil // empty object
+		return result(), nil // empty object
 	}
 	for p.ch > 0 {
 		var key string
@@ -375,6 +398,9 @@ func (p *hjsonParser) readObject(withoutBraces bool) (value interface{}, err err
 		if val, err = p.readValue(); err != nil {
 			return nil, err
 		}
+		if _, dup := object[key]; !dup {
+			keys = append(keys, key)
+		}
 		object[key] = val
 		p.white()
 		// in Hjson the comma is optional and trailing commas are allowed
@@ -384,13 +410,13 @@ func (p *hjsonParser) readObject(withoutBraces bool) (value interface{}, err err
 		}
 		if p.ch == '}' && !withoutBraces {
 			p.next()
-			return object, nil
+			return result(), nil
 		}
 		p.white()
 	}
 
 	if withoutBraces {
-		return object, nil
+		return result(), nil
 	}
 	return nil, p.errAt("End of input while parsing an object (did you forget a closing '}'?)")
 }
@@ -456,7 +482,7 @@ func (p *hjsonParser) checkTrailing(v interface{}, err error) (interface{}, erro
 //
 func Unmarshal(data []byte, v interface{}) (err error) {
 	var value interface{}
-	parser := &hjsonParser{data, 0, ' '}
+	parser := &hjsonParser{data: data}
 	parser.resetAt()
 	value, err = parser.rootValue()
 	if err != nil {
@@ -478,3 +504,11 @@ func Unmarshal(data []byte, v interface{}) (err error) {
 	rv.Set(reflect.ValueOf(value))
 	return err
 }
+
+// UnmarshalOrdered parses the Hjson-encoded data, decoding objects as
+// *Object to keep the order of their keys.
+func UnmarshalOrdered(data []byte) (interface{}, error) {
+	parser := &hjsonParser{data: data, ordered: true}
+	parser.resetAt()
+	return parser.rootValue()
+}
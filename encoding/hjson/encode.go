@@ -195,12 +195,60 @@ func (e *hjsonEncoder) useMarshaler(value reflect.Value, separator string) error
 
 var marshaler = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
 
+// Ordered is implemented by objects keeping their keys in order, they are
+// encoded in that order instead of sorted.
+type Ordered interface {
+	OrderedKeys() []string
+	OrderedValue(key string) interface{}
+}
+
+func (e *hjsonEncoder) ordered(o Ordered, noIndent bool, separator string) error {
+	keys := o.OrderedKeys()
+	if len(keys) == 0 {
+		e.WriteString(separator)
+		e.WriteString("{}")
+		return nil
+	}
+
+	indent1 := e.indent
+	e.indent++
+	if !noIndent && !e.BracesSameLine {
+		e.writeIndent(indent1)
+	} else {
+		e.WriteString(separator)
+	}
+	e.WriteString("{")
+
+	for _, k := range keys {
+		v := o.OrderedValue(k)
+		e.writeIndent(e.indent)
+		e.WriteString(e.quoteName(k))
+		e.WriteString(":")
+		if err := e.str(reflect.ValueOf(&v).Elem(), false, " ", false); err != nil {
+			return err
+		}
+	}
+
+	e.writeIndent(indent1)
+	e.WriteString("}")
+	e.indent = indent1
+	return nil
+}
+
 func (e *hjsonEncoder) str(value reflect.Value, noIndent bool, separator string, isRootObject bool) error {
 
 	// Produce a string from value.
 
 	kind := value.Kind()
 
+	if value.IsValid() && value.CanInterface() {
+		if o, ok := value.Interface().(Ordered); ok {
+			if rv := reflect.ValueOf(o); rv.Kind() != reflect.Ptr || !rv.IsNil() {
+				return e.ordered(o, noIndent, separator)
+			}
+		}
+	}
+
 	if kind == reflect.Interface || kind == reflect.Ptr {
 		if value.IsNil() {
 			e.WriteString(separator)
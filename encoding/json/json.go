@@ -2,10 +2,9 @@ package json
 
 import (
 	"bytes"
-	"fmt"
 	"io"
 	"os"
-
+	"path/filepath"
 	"strings"
 
 	gojson "github.com/goccy/go-json"
@@ -29,6 +28,10 @@ func Marshal(v interface{}) ([]byte, error) {
 	return gojson.Marshal(v)
 }
 
+func MarshalIndent(v interface{}, prefix, indent string) ([]byte, error) {
+	return gojson.MarshalIndent(v, prefix, indent)
+}
+
 func Unmarshal(data []byte, v interface{}) error {
 	return gojson.Unmarshal(data, v)
 }
@@ -130,10 +133,38 @@ func LoadWithErr(src string) (data RawValue, err error) {
 	return
 }
 
+// Save writes data to src through a temporary file renamed over it, so
+// readers never see a partial file, see WriteFileAtomic.
 func Save(src string, data []byte) (err error) {
-	fmt.Println("saving json", src)
-	err = afero.WriteFile(fs, src, data, os.FileMode(0755))
+	return WriteFileAtomic(fs, strings.TrimPrefix(src, "file:"), data, 0644)
+}
 
+// WriteFileAtomic writes data to a temporary file in the directory of path,
+// then renames it over path. An existing file keeps its permissions, mode is
+// used for a new one.
+func WriteFileAtomic(fs afero.Fs, path string, data []byte, mode os.FileMode) (err error) {
+	if fi, e := fs.Stat(path); e == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := afero.TempFile(fs, filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return
+	}
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err == nil {
+		err = fs.Chmod(f.Name(), mode)
+	}
+	if err == nil {
+		err = fs.Rename(f.Name(), path)
+	}
+	if err != nil {
+		fs.Remove(f.Name())
+	}
 	return
 }
 
@@ -0,0 +1,300 @@
+package json
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	gojson "github.com/goccy/go-json"
+)
+
+// LinePolicy decides what a LineReader does with an invalid line.
+type LinePolicy uint8
+
+const (
+	// LineFail stops reading at the first invalid line.
+	LineFail LinePolicy = iota
+	// LineSkip skips invalid lines, reporting them to LineOptions.OnSkip.
+	LineSkip
+)
+
+// LineOptions configures LineReader and LineWriter.
+type LineOptions struct {
+	Policy LinePolicy
+	// OnSkip receives the lines skipped by LineSkip.
+	OnSkip func(err *LineError)
+	// MaxLine limits the size of a line in bytes, 0 means no limit.
+	MaxLine int
+	// Gzip compresses the written lines. Readers detect gzip by themselves.
+	Gzip bool
+}
+
+// LineError is an invalid line of a JSON Lines stream.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("json lines: line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+var ErrLineTooLong = errors.New("line too long")
+
+// LineReader reads a JSON Lines (NDJSON) stream one value at a time.
+type LineReader struct {
+	r      *bufio.Reader
+	opt    LineOptions
+	line   int
+	closer io.Closer
+	err    error
+}
+
+// NewLineReader returns a LineReader reading r, which may be gzip
+// compressed.
+func NewLineReader(r io.Reader, opts ...LineOptions) *LineReader {
+	lr := &LineReader{r: bufio.NewReaderSize(r, 64*1024)}
+	if len(opts) > 0 {
+		lr.opt = opts[0]
+	}
+	if magic, err := lr.r.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(lr.r)
+		if err != nil {
+			lr.err = err
+			return lr
+		}
+		lr.closer = gz
+		lr.r = bufio.NewReaderSize(gz, 64*1024)
+	}
+	return lr
+}
+
+// OpenLineReader opens the JSON Lines file src.
+func OpenLineReader(src string, opts ...LineOptions) (lr *LineReader, err error) {
+	f, err := fs.Open(strings.TrimPrefix(src, "file:"))
+	if err != nil {
+		return
+	}
+	lr = NewLineReader(f, opts...)
+	if lr.closer != nil {
+		lr.closer = multiCloser{lr.closer, f}
+	} else {
+		lr.closer = f
+	}
+	return
+}
+
+type multiCloser []io.Closer
+
+func (m multiCloser) Close() (err error) {
+	for _, c := range m {
+		if e := c.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+// Line returns the number of the last line read.
+func (lr *LineReader) Line() int {
+	return lr.line
+}
+
+// Read returns the next value, blank lines are ignored. At the end of the
+// stream it returns io.EOF.
+func (lr *LineReader) Read() (v RawValue, err error) {
+	for {
+		if lr.err != nil {
+			return nil, lr.err
+		}
+		var data []byte
+		data, err = lr.readLine()
+		if err != nil && (err != io.EOF || len(data) == 0) {
+			lr.err = err
+			return nil, err
+		}
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		if !gojson.Valid(data) {
+			if err = lr.invalid(errors.New("invalid JSON")); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return RawValue(data), nil
+	}
+}
+
+// ReadMap returns the next value, which must be an object.
+func (lr *LineReader) ReadMap() (m map[string]any, err error) {
+	for {
+		v, err := lr.Read()
+		if err != nil {
+			return nil, err
+		}
+		if e := Decode(v, &m); e != nil || m == nil {
+			if e == nil {
+				e = errors.New("not an object")
+			}
+			if err = lr.invalid(e); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return m, nil
+	}
+}
+
+func (lr *LineReader) invalid(err error) error {
+	le := &LineError{Line: lr.line, Err: err}
+	if lr.opt.Policy == LineSkip {
+		if lr.opt.OnSkip != nil {
+			lr.opt.OnSkip(le)
+		}
+		return nil
+	}
+	lr.err = le
+	return le
+}
+
+// readLine returns the next line, without its end of line.
+func (lr *LineReader) readLine() (line []byte, err error) {
+	lr.line++
+	tooLong := false
+	for {
+		chunk, e := lr.r.ReadSlice('\n')
+		if !tooLong {
+			line = append(line, chunk...)
+			if lr.opt.MaxLine > 0 && len(line) > lr.opt.MaxLine+1 {
+				tooLong, line = true, nil
+			}
+		}
+		if e == bufio.ErrBufferFull {
+			continue
+		}
+		if tooLong && (e == nil || e == io.EOF) {
+			if err = lr.invalid(ErrLineTooLong); err != nil {
+				return nil, err
+			}
+			if e == io.EOF {
+				return nil, io.EOF
+			}
+			lr.line++
+			tooLong = false
+			continue
+		}
+		return line, e
+	}
+}
+
+// Close closes the gzip stream and the file opened by OpenLineReader.
+func (lr *LineReader) Close() error {
+	if lr.closer != nil {
+		return lr.closer.Close()
+	}
+	return nil
+}
+
+// LineWriter writes values as JSON Lines.
+type LineWriter struct {
+	w      *bufio.Writer
+	gz     *gzip.Writer
+	closer io.Closer
+}
+
+// NewLineWriter returns a LineWriter to w, Close must be called to flush the
+// buffered lines.
+func NewLineWriter(w io.Writer, opts ...LineOptions) *LineWriter {
+	lw := &LineWriter{}
+	if len(opts) > 0 && opts[0].Gzip {
+		lw.gz = gzip.NewWriter(w)
+		w = lw.gz
+	}
+	lw.w = bufio.NewWriter(w)
+	return lw
+}
+
+// OpenLineWriter appends to the JSON Lines file src, creating it when
+// needed. A ".gz" file is gzip compressed, each session adding a gzip member.
+func OpenLineWriter(src string, opts ...LineOptions) (lw *LineWriter, err error) {
+	src = strings.TrimPrefix(src, "file:")
+	f, err := fs.OpenFile(src, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return
+	}
+	var opt LineOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Gzip = opt.Gzip || strings.HasSuffix(src, ".gz")
+	lw = NewLineWriter(f, opt)
+	lw.closer = f
+	return
+}
+
+// Write encodes v on a single line. RawValue, []byte and string values are
+// written as they are, once compacted.
+func (lw *LineWriter) Write(v any) (err error) {
+	var data []byte
+	switch val := v.(type) {
+	case RawValue:
+		data = val
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	default:
+		if data, err = gojson.Marshal(v); err != nil {
+			return
+		}
+	}
+	if bytes.ContainsAny(data, "\r\n") {
+		var buf bytes.Buffer
+		if err = gojson.Compact(&buf, data); err != nil {
+			return
+		}
+		data = buf.Bytes()
+	} else if !gojson.Valid(data) {
+		return errors.New("json lines: invalid JSON value")
+	}
+	if _, err = lw.w.Write(data); err == nil {
+		err = lw.w.WriteByte('\n')
+	}
+	return
+}
+
+// Flush writes the buffered lines, gzip data is only complete after Close.
+func (lw *LineWriter) Flush() (err error) {
+	if err = lw.w.Flush(); err == nil && lw.gz != nil {
+		err = lw.gz.Flush()
+	}
+	return
+}
+
+// Close flushes the lines, ends the gzip stream and closes the file opened by
+// OpenLineWriter.
+func (lw *LineWriter) Close() (err error) {
+	err = lw.w.Flush()
+	if lw.gz != nil {
+		if e := lw.gz.Close(); err == nil {
+			err = e
+		}
+	}
+	if lw.closer != nil {
+		if e := lw.closer.Close(); err == nil {
+			err = e
+		}
+	}
+	return
+}
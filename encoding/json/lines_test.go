@@ -0,0 +1,79 @@
+package json
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	src := "{\"id\":1}\n\n{bad}\n[1,2]\n{\"id\":2}"
+	lr := NewLineReader(strings.NewReader(src))
+	m, err := lr.ReadMap()
+	if err != nil || m["id"] != float64(1) {
+		t.Fatalf("ReadMap = %v, %v", m, err)
+	}
+	_, err = lr.ReadMap()
+	var le *LineError
+	if !errors.As(err, &le) || le.Line != 3 {
+		t.Fatalf("error = %v", err)
+	}
+
+	var skipped []int
+	lr = NewLineReader(strings.NewReader(src), LineOptions{Policy: LineSkip, OnSkip: func(e *LineError) {
+		skipped = append(skipped, e.Line)
+	}})
+	var ids []float64
+	for {
+		m, err := lr.ReadMap()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m["id"].(float64))
+	}
+	if len(ids) != 2 || ids[1] != 2 || len(skipped) != 2 || skipped[0] != 3 || skipped[1] != 4 {
+		t.Errorf("ids = %v, skipped = %v", ids, skipped)
+	}
+
+	lr = NewLineReader(strings.NewReader("{\"a\":\"xxxxxxxx\"}\n{}\n"), LineOptions{MaxLine: 8, Policy: LineSkip})
+	if v, err := lr.Read(); err != nil || string(v) != "{}" || lr.Line() != 2 {
+		t.Errorf("Read = %s, %v at line %d", v, err, lr.Line())
+	}
+}
+
+func TestLineWriter(t *testing.T) {
+	for _, gz := range []bool{false, true} {
+		var buf bytes.Buffer
+		lw := NewLineWriter(&buf, LineOptions{Gzip: gz})
+		lw.Write(map[string]any{"id": 1})
+		lw.Write(RawValue("{\n  \"id\": 2\n}"))
+		if err := lw.Write("{bad"); err == nil {
+			t.Error("invalid raw value should fail")
+		}
+		if err := lw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if !gz && buf.String() != "{\"id\":1}\n{\"id\":2}\n" {
+			t.Errorf("written = %q", buf.String())
+		}
+		lr := NewLineReader(&buf)
+		var n int
+		for {
+			if _, err := lr.Read(); err != nil {
+				if err != io.EOF {
+					t.Fatal(err)
+				}
+				break
+			}
+			n++
+		}
+		if n != 2 {
+			t.Errorf("gzip %v: read %d lines", gz, n)
+		}
+	}
+}
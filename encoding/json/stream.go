@@ -0,0 +1,259 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	gojson "github.com/goccy/go-json"
+)
+
+type Token = gojson.Token
+type Delim = gojson.Delim
+type Number = gojson.Number
+
+// Decoder reads JSON values and tokens from a stream.
+type Decoder struct {
+	d  *gojson.Decoder
+	in bool // inside the array entered by Enter
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{d: gojson.NewDecoder(r)}
+}
+
+// Decode reads the next value into v.
+func (d *Decoder) Decode(v any) error {
+	return d.d.Decode(v)
+}
+
+// Token returns the next token: Delim, bool, float64, Number, string or nil.
+// Object keys are returned as strings.
+func (d *Decoder) Token() (Token, error) {
+	return d.d.Token()
+}
+
+// More reports whether the current array or object has another element.
+func (d *Decoder) More() bool {
+	return d.d.More()
+}
+
+func (d *Decoder) UseNumber() {
+	d.d.UseNumber()
+}
+
+func (d *Decoder) InputOffset() int64 {
+	return d.d.InputOffset()
+}
+
+// Enter moves the decoder into the array at path, a dotted path of object
+// keys and array indexes such as "data.items" ("." escaped as "\."). An
+// empty path is the top level value. Siblings are skipped token by token,
+// so memory stays bounded by the largest element read with Next.
+func (d *Decoder) Enter(path string) (err error) {
+	for _, seg := range splitPath(path) {
+		if err = d.enter(seg); err != nil {
+			return fmt.Errorf("json: enter %s: %w", path, err)
+		}
+	}
+	tok, err := d.d.Token()
+	if err != nil {
+		return fmt.Errorf("json: enter %s: %w", path, err)
+	}
+	if tok != Delim('[') {
+		return fmt.Errorf("json: enter %s: not an array", path)
+	}
+	d.in = true
+	return
+}
+
+func (d *Decoder) enter(seg string) error {
+	tok, err := d.d.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case Delim('{'):
+		for d.d.More() {
+			key, err := d.d.Token()
+			if err != nil {
+				return err
+			}
+			if key == seg {
+				return nil
+			}
+			if err = d.skip(); err != nil {
+				return err
+			}
+		}
+	case Delim('['):
+		n, err := strconv.Atoi(seg)
+		if err != nil {
+			return fmt.Errorf("%q is not an array index", seg)
+		}
+		for x := 0; d.d.More(); x++ {
+			if x == n {
+				return nil
+			}
+			if err = d.skip(); err != nil {
+				return err
+			}
+		}
+	}
+	return fmt.Errorf("%q not found", seg)
+}
+
+// skip reads the next value without keeping it.
+func (d *Decoder) skip() error {
+	depth := 0
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case Delim('{'), Delim('['):
+			depth++
+		case Delim('}'), Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// Next decodes the next element of the array entered by Enter into v, it
+// returns io.EOF after the last one.
+func (d *Decoder) Next(v any) error {
+	if !d.in {
+		return errors.New("json: Next called before Enter")
+	}
+	if !d.d.More() {
+		d.in = false
+		if _, err := d.d.Token(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	return d.d.Decode(v)
+}
+
+func splitPath(path string) (segs []string) {
+	if path == "" {
+		return
+	}
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path):
+			i++
+			b.WriteByte(path[i])
+		case path[i] == '.':
+			segs = append(segs, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(path[i])
+		}
+	}
+	return append(segs, b.String())
+}
+
+type encState struct {
+	delim byte
+	n     int
+	key   bool // the next token of the object is a key
+}
+
+// Encoder writes JSON values and tokens to a stream, adding the commas and
+// colons between them. Top level values are separated by newlines.
+type Encoder struct {
+	w     io.Writer
+	stack []encState
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v as the next value.
+func (e *Encoder) Encode(v any) (err error) {
+	data, err := gojson.Marshal(v)
+	if err != nil {
+		return
+	}
+	return e.value(data)
+}
+
+// WriteToken writes a Delim to open or close an array or object, an object
+// key, or a scalar value.
+func (e *Encoder) WriteToken(t Token) (err error) {
+	if d, ok := t.(Delim); ok {
+		switch d {
+		case '{', '[':
+			if err = e.value([]byte{byte(d)}); err != nil {
+				return
+			}
+			e.stack = append(e.stack, encState{delim: byte(d), key: d == '{'})
+			return
+		case '}', ']':
+			n := len(e.stack)
+			if n == 0 || (d == '}') != (e.stack[n-1].delim == '{') || (d == '}' && !e.stack[n-1].key) {
+				return fmt.Errorf("json: unexpected %s", d)
+			}
+			e.stack = e.stack[:n-1]
+			_, err = e.w.Write([]byte{byte(d)})
+			if err == nil && len(e.stack) == 0 {
+				_, err = e.w.Write([]byte{'\n'})
+			}
+			return
+		}
+		return fmt.Errorf("json: invalid delim %s", d)
+	}
+	if n := len(e.stack); n > 0 && e.stack[n-1].key {
+		key, ok := t.(string)
+		if !ok {
+			return fmt.Errorf("json: object key must be a string, not %T", t)
+		}
+		data, _ := gojson.Marshal(key)
+		s := &e.stack[n-1]
+		if s.n > 0 {
+			data = append([]byte{','}, data...)
+		}
+		s.key = false
+		_, err = e.w.Write(append(data, ':'))
+		return
+	}
+	return e.Encode(t)
+}
+
+// value writes data at the current position.
+func (e *Encoder) value(data []byte) (err error) {
+	n := len(e.stack)
+	if n == 0 {
+		if len(data) == 1 && (data[0] == '{' || data[0] == '[') {
+			_, err = e.w.Write(data)
+			return
+		}
+		_, err = e.w.Write(append(data, '\n'))
+		return
+	}
+	s := &e.stack[n-1]
+	if s.delim == '{' {
+		if s.key {
+			return errors.New("json: object key expected")
+		}
+		s.key = true
+		s.n++
+		_, err = e.w.Write(data)
+		return
+	}
+	if s.n > 0 {
+		data = append([]byte{','}, data...)
+	}
+	s.n++
+	_, err = e.w.Write(data)
+	return
+}
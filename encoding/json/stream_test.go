@@ -0,0 +1,58 @@
+package json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecoderEnter(t *testing.T) {
+	d := NewDecoder(strings.NewReader(`[[0], [{"k": "v"}]]`))
+	if err := d.Enter("1"); err != nil {
+		t.Fatal(err)
+	}
+	var v any
+	if err := d.Next(&v); err != nil || v.(map[string]any)["k"] != "v" {
+		t.Errorf("Next = %v, %v", v, err)
+	}
+	if err := d.Next(&v); err != io.EOF {
+		t.Errorf("Next at end = %v", err)
+	}
+}
+
+func TestEncoderTokens(t *testing.T) {
+	var buf bytes.Buffer
+	e := NewEncoder(&buf)
+	e.WriteToken(Delim('{'))
+	e.WriteToken("items")
+	e.WriteToken(Delim('['))
+	for i := 0; i < 3; i++ {
+		e.Encode(map[string]any{"id": i})
+	}
+	e.WriteToken(Delim(']'))
+	e.WriteToken("n")
+	e.WriteToken(3)
+	if err := e.WriteToken(Delim(']')); err == nil {
+		t.Error("mismatched delim should fail")
+	}
+	e.WriteToken(Delim('}'))
+	e.Encode("next")
+	want := `{"items":[{"id":0},{"id":1},{"id":2}],"n":3}` + "\n\"next\"\n"
+	if buf.String() != want {
+		t.Errorf("encoded = %q, want %q", buf.String(), want)
+	}
+
+	d := NewDecoder(&buf)
+	var toks []Token
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			break
+		}
+		toks = append(toks, tok)
+	}
+	if len(toks) != 20 || toks[1] != "items" || toks[19] != "next" {
+		t.Errorf("tokens = %v", toks)
+	}
+}
@@ -0,0 +1,164 @@
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/afero"
+)
+
+var fs = afero.NewOsFs()
+
+// Error is a TOML syntax error at Line and Column, both starting at 1.
+type Error struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("toml: %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Encode encodes v, a map or a struct, as a TOML document. Keys are sorted,
+// plain values are written before tables so the output is stable across
+// round trips. nil values are left out, in maps and arrays, TOML has no null.
+func Encode(v any) (data []byte, err error) {
+	var buf bytes.Buffer
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Map {
+		v = stripNil(rv)
+	}
+	if err = toml.NewEncoder(&buf).Encode(v); err == nil {
+		data = buf.Bytes()
+	}
+	return
+}
+
+// Decode decodes a TOML document into v. A *any or a *map[string]any
+// receives map[string]any and []any values, arrays of tables included.
+// Datetimes, local or not, are decoded as time.Time.
+func Decode(data []byte, v any) (err error) {
+	switch dst := v.(type) {
+	case *any:
+		var m map[string]any
+		if err = decode(data, &m); err == nil {
+			*dst = normalize(m)
+		}
+		return
+	case *map[string]any:
+		var m map[string]any
+		if err = decode(data, &m); err == nil {
+			*dst = normalize(m).(map[string]any)
+		}
+		return
+	}
+	return decode(data, v)
+}
+
+func decode(data []byte, v any) error {
+	_, err := toml.NewDecoder(bytes.NewReader(data)).Decode(v)
+	var pe toml.ParseError
+	if errors.As(err, &pe) {
+		col := pe.Position.Start + 1
+		if pe.Position.Start <= len(data) {
+			col -= bytes.LastIndexByte(data[:pe.Position.Start], '\n') + 1
+		}
+		return &Error{Line: pe.Position.Line, Column: col, Msg: pe.Message}
+	}
+	return err
+}
+
+// normalize converts arrays of tables into []any.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, e := range val {
+			val[k] = normalize(e)
+		}
+	case []map[string]any:
+		arr := make([]any, len(val))
+		for x, e := range val {
+			arr[x] = normalize(e)
+		}
+		return arr
+	case []any:
+		for x, e := range val {
+			val[x] = normalize(e)
+		}
+	}
+	return v
+}
+
+// stripNil copies the string keyed maps and the arrays of v without their nil
+// values.
+func stripNil(rv reflect.Value) any {
+	for rv.Kind() == reflect.Interface && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	switch {
+	case rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String:
+		out := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			if !isNil(iter.Value()) {
+				out[iter.Key().String()] = stripNil(iter.Value())
+			}
+		}
+		return out
+	case (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array:
+		out := make([]any, 0, rv.Len())
+		for x := 0; x < rv.Len(); x++ {
+			if !isNil(rv.Index(x)) {
+				out = append(out, stripNil(rv.Index(x)))
+			}
+		}
+		return out
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
+
+func isNil(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// Load reads a TOML file, src can be prefixed with "file:".
+func Load(src string) (data []byte) {
+	data, _ = LoadWithErr(src)
+	return
+}
+
+func LoadWithErr(src string) (data []byte, err error) {
+	return afero.ReadFile(fs, strings.TrimPrefix(src, "file:"))
+}
+
+// Read decodes the TOML file src into v.
+func Read(src string, v any) (err error) {
+	data, err := LoadWithErr(src)
+	if err != nil {
+		return
+	}
+	return Decode(data, v)
+}
+
+// Save encodes v into the TOML file src.
+func Save(src string, v any) (err error) {
+	data, err := Encode(v)
+	if err != nil {
+		return
+	}
+	return afero.WriteFile(fs, strings.TrimPrefix(src, "file:"), data, os.FileMode(0644))
+}
@@ -0,0 +1,402 @@
+package xml
+
+import (
+	"bytes"
+	stdxml "encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const xmlURL = "http://www.w3.org/XML/1998/namespace"
+
+// Options sets the conventions used to map XML onto maps.
+type Options struct {
+	// AttrPrefix is prepended to attribute names, defaults to "@".
+	AttrPrefix string
+	// TextKey holds the text of elements having attributes or children,
+	// defaults to "#text".
+	TextKey string
+	// Namespaces keeps namespace prefixes in names ("atom:link") and the
+	// xmlns attributes, otherwise only local names are used.
+	Namespaces bool
+	// ForceArray lists element names always decoded as arrays, even when
+	// they appear once.
+	ForceArray []string
+	// Root names the root element when encoding a map with several keys,
+	// defaults to "root".
+	Root string
+	// Indent indents the encoded elements.
+	Indent string
+}
+
+func options(opts []Options) (o Options) {
+	if len(opts) > 0 {
+		o = opts[0]
+	}
+	if o.AttrPrefix == "" {
+		o.AttrPrefix = "@"
+	}
+	if o.TextKey == "" {
+		o.TextKey = "#text"
+	}
+	if o.Root == "" {
+		o.Root = "root"
+	}
+	return
+}
+
+func (o Options) forceArray(name string) bool {
+	for _, n := range o.ForceArray {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode encodes v as an XML document. A map with a single key is encoded as
+// that root element, otherwise the entries are wrapped in Options.Root.
+// Keys starting with AttrPrefix become attributes, TextKey becomes the text
+// and arrays become repeated elements. Keys are written in sorted order.
+func Encode(v map[string]any, opts ...Options) (data []byte, err error) {
+	o := options(opts)
+	var buf bytes.Buffer
+	enc := stdxml.NewEncoder(&buf)
+	if o.Indent != "" {
+		enc.Indent("", o.Indent)
+	}
+	root, val := o.Root, any(v)
+	if len(v) == 1 {
+		for k, e := range v {
+			root, val = k, e
+		}
+	}
+	if err = encodeElement(enc, o, root, val); err == nil {
+		err = enc.Flush()
+	}
+	data = buf.Bytes()
+	return
+}
+
+func encodeElement(enc *stdxml.Encoder, o Options, name string, v any) (err error) {
+	if arr, ok := asArray(v); ok {
+		for _, e := range arr {
+			if err = encodeElement(enc, o, name, e); err != nil {
+				return
+			}
+		}
+		return
+	}
+	if !validName(name) {
+		return fmt.Errorf("xml: invalid element name %q", name)
+	}
+	start := stdxml.StartElement{Name: stdxml.Name{Local: name}}
+	obj, isObj := asObject(v)
+	var children []string
+	if isObj {
+		keys := make([]string, 0, len(obj))
+		for k := range obj {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			switch {
+			case k == o.TextKey:
+			case strings.HasPrefix(k, o.AttrPrefix):
+				attr := k[len(o.AttrPrefix):]
+				if !validName(attr) {
+					return fmt.Errorf("xml: element %q: invalid attribute name %q", name, attr)
+				}
+				start.Attr = append(start.Attr, stdxml.Attr{Name: stdxml.Name{Local: attr}, Value: text(obj[k])})
+			default:
+				children = append(children, k)
+			}
+		}
+	}
+	if err = enc.EncodeToken(start); err != nil {
+		return fmt.Errorf("xml: element %q: %w", name, err)
+	}
+	if isObj {
+		if t, ok := obj[o.TextKey]; ok {
+			if err = enc.EncodeToken(stdxml.CharData(text(t))); err != nil {
+				return
+			}
+		}
+		for _, k := range children {
+			if err = encodeElement(enc, o, k, obj[k]); err != nil {
+				return
+			}
+		}
+	} else if v != nil {
+		if err = enc.EncodeToken(stdxml.CharData(text(v))); err != nil {
+			return
+		}
+	}
+	return enc.EncodeToken(start.End())
+}
+
+// validName reports whether s is an XML name: a letter, "_" or ":" followed
+// by letters, digits, ".", "-", "_", ":" or combining marks.
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for x, r := range s {
+		switch {
+		case unicode.IsLetter(r) || r == '_' || r == ':':
+		case x > 0 && (unicode.IsDigit(r) || r == '.' || r == '-' || r == '\u00b7' || unicode.In(r, unicode.Mn, unicode.Mc)):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+var mapType = reflect.TypeOf(map[string]any{})
+
+// asObject accepts map[string]any and named types of it.
+func asObject(v any) (map[string]any, bool) {
+	if obj, ok := v.(map[string]any); ok {
+		return obj, true
+	}
+	if rv := reflect.ValueOf(v); rv.IsValid() && rv.Type().ConvertibleTo(mapType) && rv.Kind() == reflect.Map {
+		return rv.Convert(mapType).Interface().(map[string]any), true
+	}
+	return nil, false
+}
+
+// asArray accepts slices and arrays, except []byte.
+func asArray(v any) ([]any, bool) {
+	if arr, ok := v.([]any); ok {
+		return arr, true
+	}
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array {
+		arr := make([]any, rv.Len())
+		for x := range arr {
+			arr[x] = rv.Index(x).Interface()
+		}
+		return arr, true
+	}
+	return nil, false
+}
+
+func text(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case []byte:
+		return string(val)
+	}
+	return fmt.Sprint(v)
+}
+
+// Decode decodes an XML document into a map holding the root element.
+// Elements without attributes or children are decoded as strings.
+func Decode(data []byte, opts ...Options) (v map[string]any, err error) {
+	d := NewDecoder(bytes.NewReader(data), "", opts...)
+	v, err = d.Next()
+	if err == io.EOF {
+		err = errors.New("xml: no root element")
+	}
+	return
+}
+
+type frame struct {
+	name   string
+	obj    map[string]any
+	text   strings.Builder
+	ns     map[string]string // uri -> prefix declared on the element
+	keep   bool
+	record bool
+}
+
+// Decoder reads records from an XML stream without loading it whole.
+type Decoder struct {
+	d      *stdxml.Decoder
+	o      Options
+	path   []string
+	stack  []*frame
+	err    error
+	inside int // depth of the record being decoded, 0 when outside
+}
+
+// NewDecoder returns a Decoder yielding the elements at path, a "/"
+// separated list of element names matched against the end of the current
+// element path, ie: "entry" or "feed/entry". An empty path yields the root
+// element. Only the records are kept in memory.
+func NewDecoder(r io.Reader, path string, opts ...Options) *Decoder {
+	d := &Decoder{d: stdxml.NewDecoder(r), o: options(opts)}
+	if path != "" {
+		d.path = strings.Split(strings.Trim(path, "/"), "/")
+	}
+	return d
+}
+
+func (d *Decoder) matches(name string) bool {
+	if len(d.path) == 0 {
+		return len(d.stack) == 0
+	}
+	if d.path[len(d.path)-1] != name || len(d.stack) < len(d.path)-1 {
+		return false
+	}
+	for x, p := range d.path[:len(d.path)-1] {
+		if d.stack[len(d.stack)-len(d.path)+1+x].name != p {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *Decoder) prefix(uri string) (string, bool) {
+	if uri == xmlURL {
+		return "xml", true
+	}
+	for x := len(d.stack) - 1; x >= 0; x-- {
+		if p, ok := d.stack[x].ns[uri]; ok {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func (d *Decoder) name(n stdxml.Name) string {
+	if !d.o.Namespaces || n.Space == "" {
+		return n.Local
+	}
+	p, ok := d.prefix(n.Space)
+	if !ok {
+		// undeclared prefixes are left untranslated
+		p = n.Space
+	}
+	if p == "" {
+		return n.Local
+	}
+	return p + ":" + n.Local
+}
+
+// Next returns the next record, or io.EOF at the end of the stream.
+func (d *Decoder) Next() (rec map[string]any, err error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	for {
+		tok, err := d.d.Token()
+		if err != nil {
+			if err == io.EOF && len(d.stack) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			d.err = err
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case stdxml.StartElement:
+			f := &frame{ns: map[string]string{}}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" {
+					f.ns[a.Value] = a.Name.Local
+				} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					f.ns[a.Value] = ""
+				}
+			}
+			d.stack = append(d.stack, f)
+			f.name = d.name(t.Name)
+			d.stack = d.stack[:len(d.stack)-1]
+			f.record = d.inside == 0 && d.matches(f.name)
+			f.keep = f.record || d.inside > 0
+			d.stack = append(d.stack, f)
+			if f.record {
+				d.inside = len(d.stack)
+			}
+			if !f.keep {
+				continue
+			}
+			f.obj = map[string]any{}
+			for _, a := range t.Attr {
+				isNS := a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns")
+				if isNS && !d.o.Namespaces {
+					continue
+				}
+				key := a.Name.Local
+				if a.Name.Space == "xmlns" {
+					key = "xmlns:" + a.Name.Local
+				} else if !isNS {
+					key = d.name(a.Name)
+				}
+				f.obj[d.o.AttrPrefix+key] = a.Value
+			}
+		case stdxml.CharData:
+			if f := d.top(); f != nil && f.keep {
+				f.text.Write(t)
+			}
+		case stdxml.EndElement:
+			f := d.stack[len(d.stack)-1]
+			d.stack = d.stack[:len(d.stack)-1]
+			if !f.keep {
+				continue
+			}
+			v := d.value(f)
+			if f.record {
+				d.inside = 0
+				if len(d.path) == 0 {
+					return map[string]any{f.name: v}, nil
+				}
+				if obj, ok := v.(map[string]any); ok {
+					return obj, nil
+				}
+				return map[string]any{d.o.TextKey: v}, nil
+			}
+			d.add(d.top().obj, f.name, v)
+		}
+	}
+}
+
+func (d *Decoder) top() *frame {
+	if len(d.stack) == 0 {
+		return nil
+	}
+	return d.stack[len(d.stack)-1]
+}
+
+func (d *Decoder) value(f *frame) any {
+	s := f.text.String()
+	if len(f.obj) == 0 {
+		if strings.TrimSpace(s) == "" {
+			return ""
+		}
+		return s
+	}
+	if s = strings.TrimSpace(s); s != "" {
+		f.obj[d.o.TextKey] = s
+	}
+	return f.obj
+}
+
+func (d *Decoder) add(obj map[string]any, name string, v any) {
+	prev, ok := obj[name]
+	switch {
+	case !ok && d.o.forceArray(name):
+		obj[name] = []any{v}
+	case !ok:
+		obj[name] = v
+	default:
+		if arr, isArr := prev.([]any); isArr {
+			obj[name] = append(arr, v)
+		} else {
+			obj[name] = []any{prev, v}
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package yaml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Error is a YAML error at a position of the source, Line and Column start
+// at 1. Syntax errors reported by the parser only carry a line, their Column
+// is 0.
+type Error struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.Line == 0:
+		return "yaml: " + e.Msg
+	case e.Column == 0:
+		return fmt.Sprintf("yaml: line %d: %s", e.Line, e.Msg)
+	}
+	return fmt.Sprintf("yaml: %d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func nodeError(n *yamlv3.Node, format string, args ...any) error {
+	return &Error{Line: n.Line, Column: n.Column, Msg: fmt.Sprintf(format, args...)}
+}
+
+var lineMsg = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// wrapError converts the errors of the parser into an *Error.
+func wrapError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	var te *yamlv3.TypeError
+	if errors.As(err, &te) {
+		return err
+	}
+	if m := lineMsg.FindStringSubmatch(err.Error()); m != nil {
+		line, _ := strconv.Atoi(m[1])
+		return &Error{Line: line, Msg: m[2]}
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+	return &Error{Msg: stripPrefix(err)}
+}
+
+// Encode encodes v as a YAML document in block style, or in flow style when
+// flow is set.
+func Encode(v any, flow ...bool) (data []byte, err error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = encode(enc, v, flow...); err == nil {
+		err = enc.Close()
+	}
+	data = buf.Bytes()
+	return
+}
+
+// EncodeAll encodes each value as a document of a multi-document stream.
+func EncodeAll(docs []any, flow ...bool) (data []byte, err error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, v := range docs {
+		if err = encode(enc, v, flow...); err != nil {
+			return
+		}
+	}
+	err = enc.Close()
+	data = buf.Bytes()
+	return
+}
+
+func encode(enc *yamlv3.Encoder, v any, flow ...bool) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("yaml: %v", r)
+		}
+	}()
+	if len(flow) == 0 || !flow[0] {
+		return enc.Encode(v)
+	}
+	var n yamlv3.Node
+	if err = n.Encode(v); err != nil {
+		return
+	}
+	setFlow(&n)
+	return enc.Encode(&n)
+}
+
+func setFlow(n *yamlv3.Node) {
+	if n.Kind == yamlv3.MappingNode || n.Kind == yamlv3.SequenceNode {
+		n.Style |= yamlv3.FlowStyle
+	}
+	for _, c := range n.Content {
+		setFlow(c)
+	}
+}
+
+// Decode decodes the first document of data into v. A *any or a
+// *map[string]any receives map[string]any, []any and scalar values, other
+// targets are decoded by the YAML library.
+func Decode(data []byte, v any) (err error) {
+	dec := yamlv3.NewDecoder(bytes.NewReader(data))
+	var n yamlv3.Node
+	if err = dec.Decode(&n); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		return wrapError(err)
+	}
+	return decodeNode(&n, v)
+}
+
+// DecodeAll decodes every document of a multi-document stream.
+func DecodeAll(data []byte) (docs []any, err error) {
+	dec := yamlv3.NewDecoder(bytes.NewReader(data))
+	for {
+		var n yamlv3.Node
+		if err = dec.Decode(&n); err != nil {
+			if errors.Is(err, io.EOF) {
+				return docs, nil
+			}
+			return docs, wrapError(err)
+		}
+		var v any
+		if err = decodeNode(&n, &v); err != nil {
+			return
+		}
+		docs = append(docs, v)
+	}
+}
+
+func decodeNode(n *yamlv3.Node, v any) (err error) {
+	switch dst := v.(type) {
+	case *any:
+		*dst, err = newConverter().toValue(n)
+		return
+	case *map[string]any:
+		var val any
+		if val, err = newConverter().toValue(n); err != nil {
+			return
+		}
+		obj, ok := val.(map[string]any)
+		if !ok && val != nil {
+			return nodeError(n, "cannot decode %s into map[string]any", kindName(val))
+		}
+		*dst = obj
+		return
+	}
+	return wrapError(n.Decode(v))
+}
+
+func kindName(v any) string {
+	if v == nil {
+		return "null"
+	}
+	return reflect.TypeOf(v).String()
+}
+
+// maxAliasNodes bounds the nodes produced by expanding aliases, so that
+// documents nesting aliases ("billion laughs") fail instead of exhausting
+// memory.
+const maxAliasNodes = 1000000
+
+type converter struct {
+	active  map[*yamlv3.Node]bool // anchors being expanded
+	aliased int                   // nodes produced inside aliases
+}
+
+func newConverter() *converter {
+	return &converter{active: map[*yamlv3.Node]bool{}}
+}
+
+// toValue converts a node into generic values, resolving aliases and
+// merge keys.
+func (c *converter) toValue(n *yamlv3.Node) (v any, err error) {
+	if len(c.active) > 0 {
+		if c.aliased++; c.aliased > maxAliasNodes {
+			return nil, nodeError(n, "excessive aliasing")
+		}
+	}
+	switch n.Kind {
+	case yamlv3.DocumentNode:
+		if len(n.Content) == 0 {
+			return nil, nil
+		}
+		return c.toValue(n.Content[0])
+	case yamlv3.AliasNode:
+		if c.active[n.Alias] {
+			return nil, nodeError(n, "alias *%s refers to itself", n.Value)
+		}
+		c.active[n.Alias] = true
+		defer delete(c.active, n.Alias)
+		return c.toValue(n.Alias)
+	case yamlv3.ScalarNode:
+		if err = n.Decode(&v); err != nil {
+			return nil, nodeError(n, "%s", stripPrefix(err))
+		}
+		return
+	case yamlv3.SequenceNode:
+		arr := make([]any, len(n.Content))
+		for x, e := range n.Content {
+			if arr[x], err = c.toValue(e); err != nil {
+				return
+			}
+		}
+		return arr, nil
+	case yamlv3.MappingNode:
+		obj := make(map[string]any, len(n.Content)/2)
+		// merged keys never override the keys of the mapping itself
+		var merges []*yamlv3.Node
+		keys := map[string]*yamlv3.Node{}
+		for x := 0; x+1 < len(n.Content); x += 2 {
+			kn, vn := n.Content[x], n.Content[x+1]
+			if kn.Kind == yamlv3.ScalarNode && kn.Tag == "!!merge" {
+				merges = append(merges, vn)
+				continue
+			}
+			k, err := keyOf(kn)
+			if err != nil {
+				return nil, err
+			}
+			if prev, ok := keys[k]; ok {
+				return nil, nodeError(kn, "mapping key %q already defined at line %d", k, prev.Line)
+			}
+			keys[k] = kn
+			if obj[k], err = c.toValue(vn); err != nil {
+				return nil, err
+			}
+		}
+		for _, vn := range merges {
+			if err = c.merge(obj, vn); err != nil {
+				return
+			}
+		}
+		return obj, nil
+	}
+	return nil, nodeError(n, "unexpected node")
+}
+
+// merge adds the keys of a merge value missing from obj, earlier mappings of
+// a sequence win over later ones.
+func (c *converter) merge(obj map[string]any, vn *yamlv3.Node) error {
+	sources := []*yamlv3.Node{vn}
+	if vn.Kind == yamlv3.SequenceNode {
+		sources = vn.Content
+	}
+	for _, s := range sources {
+		v, err := c.toValue(s)
+		if err != nil {
+			return err
+		}
+		src, ok := v.(map[string]any)
+		if !ok {
+			return nodeError(s, "merge key needs a mapping or a sequence of mappings")
+		}
+		for k, e := range src {
+			if _, exists := obj[k]; !exists {
+				obj[k] = e
+			}
+		}
+	}
+	return nil
+}
+
+func keyOf(kn *yamlv3.Node) (string, error) {
+	for kn.Kind == yamlv3.AliasNode {
+		kn = kn.Alias
+	}
+	if kn.Kind != yamlv3.ScalarNode {
+		return "", nodeError(kn, "mapping keys must be scalars")
+	}
+	return kn.Value, nil
+}
+
+func stripPrefix(err error) string {
+	return strings.TrimPrefix(err.Error(), "yaml: ")
+}
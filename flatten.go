@@ -0,0 +1,178 @@
+package godao
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Flatten returns a single level map whose keys are the paths of the leaf
+// values joined by sep, ie: {"a.b.0.c": v}. Array indexes become numeric
+// segments, and sep or backslashes inside keys are escaped with a backslash.
+// With "." every path character is escaped, so the keys are valid Get paths.
+// Empty objects and arrays are
+// kept as values. With maxDepth > 0, values nested deeper are not flattened.
+func (m Map) Flatten(sep string, maxDepth ...int) Map {
+	depth := 0
+	if len(maxDepth) > 0 {
+		depth = maxDepth[0]
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	out := Map{}
+	if len(m) > 0 {
+		flatten(out, "", m, sep, 1, depth)
+	}
+	return out
+}
+
+func flatten(out Map, prefix string, v any, sep string, level, maxDepth int) {
+	join := func(k string) string {
+		if prefix == "" {
+			return k
+		}
+		return prefix + sep + k
+	}
+	if obj, ok := asObject(v); ok && len(obj) > 0 && (maxDepth <= 0 || level <= maxDepth) {
+		for k, e := range obj {
+			flatten(out, join(escapeKey(k, sep)), e, sep, level+1, maxDepth)
+		}
+		return
+	}
+	if arr, ok := asArray(v); ok && len(arr) > 0 && (maxDepth <= 0 || level <= maxDepth) {
+		for x, e := range arr {
+			flatten(out, join(strconv.Itoa(x)), e, sep, level+1, maxDepth)
+		}
+		return
+	}
+	out[prefix] = v
+}
+
+// escapeKey escapes sep and backslashes in k. With ".", every character
+// having a meaning in Get paths is escaped, see EscapePathKey.
+func escapeKey(k, sep string) string {
+	if sep == "." {
+		return EscapePathKey(k)
+	}
+	if strings.Contains(k, "\\") {
+		k = strings.ReplaceAll(k, "\\", "\\\\")
+	}
+	if sep != "" && strings.Contains(k, sep) {
+		k = strings.ReplaceAll(k, sep, "\\"+sep)
+	}
+	return k
+}
+
+// splitKey splits a flattened key on unescaped separators.
+func splitKey(k, sep string) (parts []string) {
+	var b strings.Builder
+	for i := 0; i < len(k); {
+		switch {
+		case k[i] == '\\' && i+1 < len(k):
+			if sep != "" && strings.HasPrefix(k[i+1:], sep) {
+				b.WriteString(sep)
+				i += 1 + len(sep)
+			} else {
+				b.WriteByte(k[i+1])
+				i += 2
+			}
+		case sep != "" && strings.HasPrefix(k[i:], sep):
+			parts = append(parts, b.String())
+			b.Reset()
+			i += len(sep)
+		default:
+			b.WriteByte(k[i])
+			i++
+		}
+	}
+	return append(parts, b.String())
+}
+
+// Unflatten rebuilds the nested map from a map produced by Flatten. Objects
+// whose keys are all indexes come back as arrays, missing indexes being nil.
+// A key that is both a value and a parent, or a parent mixing indexes and
+// other keys, fails.
+func Unflatten(flat Map, sep string) (out Map, err error) {
+	root := &flatNode{}
+	for k, v := range flat {
+		n := root
+		for _, p := range splitKey(k, sep) {
+			if n.children == nil {
+				n.children = map[string]*flatNode{}
+			}
+			if n.children[p] == nil {
+				n.children[p] = &flatNode{}
+			}
+			n = n.children[p]
+		}
+		n.val, n.leaf = v, true
+	}
+	v, err := root.build("", sep)
+	if err != nil {
+		return nil, err
+	}
+	out, _ = v.(Map)
+	if out == nil {
+		out = Map{}
+	}
+	return
+}
+
+// flatNode is a segment of the flattened keys.
+type flatNode struct {
+	val      any
+	leaf     bool
+	children map[string]*flatNode
+}
+
+func (n *flatNode) build(key, sep string) (any, error) {
+	if n.leaf {
+		if len(n.children) > 0 {
+			return nil, fmt.Errorf("unflatten %q: key is both a value and a parent", key)
+		}
+		return n.val, nil
+	}
+	keys := MapKeys(n.children)
+	sort.Strings(keys)
+	size, indexes := 0, 0
+	for _, k := range keys {
+		if i, ok := arrayIndex(k); ok {
+			indexes++
+			if i >= size {
+				size = i + 1
+			}
+		}
+	}
+	child := func(k string) (any, error) {
+		p := escapeKey(k, sep)
+		if key != "" {
+			p = key + sep + p
+		}
+		return n.children[k].build(p, sep)
+	}
+	if key != "" && indexes == len(keys) {
+		arr := make([]any, size)
+		for _, k := range keys {
+			i, _ := arrayIndex(k)
+			v, err := child(k)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = v
+		}
+		return arr, nil
+	}
+	if key != "" && indexes > 0 {
+		return nil, fmt.Errorf("unflatten %q: mixed index and key segments", key)
+	}
+	obj := make(Map, len(keys))
+	for _, k := range keys {
+		v, err := child(k)
+		if err != nil {
+			return nil, err
+		}
+		obj[k] = v
+	}
+	return obj, nil
+}
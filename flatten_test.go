@@ -0,0 +1,50 @@
+package godao
+
+import (
+	"testing"
+)
+
+func TestFlattenRoundTrip(t *testing.T) {
+	m := Map{
+		"a":     Map{"b": []any{Map{"c": 1}, "x"}},
+		"dot.k": Map{"v": true},
+		"empty": []any{},
+	}
+	flat := m.Flatten(".")
+	for _, k := range []string{"a.b.0.c", "a.b.1", `dot\.k.v`, "empty"} {
+		if _, ok := flat[k]; !ok {
+			t.Errorf("missing flat key %q in %v", k, flat)
+		}
+	}
+	if got := m.Get(`dot\.k.v`).Value(); got != flat[`dot\.k.v`] {
+		t.Errorf("flat key is not a valid path: %v", got)
+	}
+	back, err := Unflatten(flat, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := back.Diff(m); len(d) > 0 {
+		t.Errorf("round trip differs: %s", d.Bytes())
+	}
+
+	meta := Map{"x": Map{"e|f": 3, "g*": Map{"-1": "k"}}}
+	flat = meta.Flatten(".")
+	for k, v := range flat {
+		if got := meta.Get(k).Value(); got != v {
+			t.Errorf("flat key %q is not a valid path: %v", k, got)
+		}
+	}
+	if back, err = Unflatten(flat, "."); err != nil || len(back.Diff(meta)) > 0 {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+	for _, bad := range []Map{{"a.0": 1, "a.x": 2}, {"a": 1, "a.b": 2}} {
+		if got, err := Unflatten(bad, "."); err == nil {
+			t.Errorf("Unflatten(%v) = %v, should fail", bad, got)
+		}
+	}
+
+	flat = m.Flatten("__", 1)
+	if _, ok := flat["a"].(Map); !ok || len(flat) != 3 {
+		t.Errorf("max depth flatten = %v", flat)
+	}
+}
@@ -3,8 +3,10 @@ module github.com/hyprstereo/go-dao
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.4.0
 	github.com/iancoleman/strcase v0.2.0
 	github.com/spf13/afero v1.8.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,352 @@
+package godao
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/hjson"
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/tidwall/gjson"
+)
+
+// GenerateOptions configures GenerateGo.
+type GenerateOptions struct {
+	Package string // defaults to "main"
+	Name    string // name of the root type, defaults to "Root"
+	Tag     string // defaults to "json"
+}
+
+// GenerateGo emits gofmt'd Go source declaring the types that src decodes
+// into. src is a Map, []Map, []any, or JSON/HJSON given as a json.RawValue,
+// []byte or string. Every object becomes a named struct type, the shapes of
+// array elements are merged, and keys missing from some of the samples or
+// holding null become pointer fields tagged omitempty.
+func GenerateGo(src any, opts ...GenerateOptions) ([]byte, error) {
+	opt := GenerateOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Package == "" {
+		opt.Package = "main"
+	}
+	if opt.Name == "" {
+		opt.Name = "Root"
+	}
+	if opt.Tag == "" {
+		opt.Tag = "json"
+	}
+	v, err := sampleOf(src)
+	if err != nil {
+		return nil, err
+	}
+	root := &genShape{}
+	root.add(v)
+
+	g := &generator{opt: opt, names: map[string]bool{}}
+	g.names[opt.Name] = true
+	var decl string
+	if root.kinds == genObject {
+		g.declare(opt.Name, root)
+	} else {
+		elemName := singular(opt.Name)
+		if elemName == opt.Name {
+			elemName += "Item"
+		}
+		decl = fmt.Sprintf("type %s %s\n\n", opt.Name, g.typeOf(root, elemName, ""))
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "package %s\n\n", opt.Package)
+	if g.usesTime {
+		buf.WriteString("import \"time\"\n\n")
+	}
+	buf.WriteString(decl)
+	for _, t := range g.types {
+		buf.WriteString(t)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// sampleOf returns src as generic values. Numbers of JSON text are kept as
+// genNumber so that 1.0 and 1 are told apart.
+func sampleOf(src any) (any, error) {
+	var data []byte
+	switch val := src.(type) {
+	case json.RawValue:
+		data = val
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	case Map:
+		mu.RLock()
+		defer mu.RUnlock()
+		return map[string]any(val.Clone()), nil
+	case nil:
+		return nil, errors.New("generate: nil source")
+	default:
+		return src, nil
+	}
+	if gjson.ValidBytes(data) {
+		return fromGJSON(gjson.ParseBytes(data)), nil
+	}
+	var v any
+	if err := hjson.Unmarshal(data, &v); err != nil {
+		return nil, fmt.Errorf("generate: %w", err)
+	}
+	return v, nil
+}
+
+type genNumber string
+
+func fromGJSON(r gjson.Result) any {
+	switch {
+	case r.IsObject():
+		obj := map[string]any{}
+		r.ForEach(func(k, v gjson.Result) bool {
+			obj[k.String()] = fromGJSON(v)
+			return true
+		})
+		return obj
+	case r.IsArray():
+		arr := []any{}
+		r.ForEach(func(_, v gjson.Result) bool {
+			arr = append(arr, fromGJSON(v))
+			return true
+		})
+		return arr
+	case r.Type == gjson.Number:
+		return genNumber(r.Raw)
+	}
+	return r.Value()
+}
+
+const (
+	genBool uint8 = 1 << iota
+	genInt
+	genFloat
+	genString
+	genTime
+	genObject
+	genArray
+)
+
+// genShape is the merged shape of every sample seen at a position.
+type genShape struct {
+	count   int // samples, null included
+	null    bool
+	kinds   uint8
+	strings int
+	times   int // strings holding RFC 3339 times
+	objects int
+	fields  map[string]*genShape
+	elem    *genShape
+}
+
+func (s *genShape) add(v any) {
+	s.count++
+	switch val := v.(type) {
+	case nil:
+		s.null = true
+		return
+	case bool:
+		s.kinds |= genBool
+		return
+	case genNumber:
+		if strings.ContainsAny(string(val), ".eE") {
+			s.kinds |= genFloat
+		} else {
+			s.kinds |= genInt
+		}
+		return
+	case float32, float64:
+		if f := reflect.ValueOf(val).Float(); f == float64(int64(f)) {
+			s.kinds |= genInt
+		} else {
+			s.kinds |= genFloat
+		}
+		return
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		s.kinds |= genInt
+		return
+	case string:
+		s.kinds |= genString
+		s.strings++
+		if _, err := time.Parse(time.RFC3339Nano, val); err == nil {
+			s.times++
+		}
+		return
+	case time.Time:
+		s.kinds |= genTime
+		return
+	}
+	if obj, ok := asObject(v); ok {
+		s.kinds |= genObject
+		s.objects++
+		if s.fields == nil {
+			s.fields = map[string]*genShape{}
+		}
+		for k, e := range obj {
+			f, ok := s.fields[k]
+			if !ok {
+				f = &genShape{}
+				s.fields[k] = f
+			}
+			f.add(e)
+		}
+		return
+	}
+	if arr, ok := asArray(v); ok {
+		s.kinds |= genArray
+		if s.elem == nil {
+			s.elem = &genShape{}
+		}
+		for _, e := range arr {
+			s.elem.add(e)
+		}
+		return
+	}
+	if g := toGeneric(v); !reflect.DeepEqual(g, v) {
+		s.count--
+		s.add(g)
+	}
+}
+
+type generator struct {
+	opt      GenerateOptions
+	names    map[string]bool
+	types    []string
+	usesTime bool
+}
+
+// typeName returns an unused type name for hint, qualified by the parent
+// type on conflicts.
+func (g *generator) typeName(hint, parent string) string {
+	name := hint
+	if g.names[name] {
+		name = parent + hint
+	}
+	for x := 2; g.names[name]; x++ {
+		name = fmt.Sprintf("%s%s%d", parent, hint, x)
+	}
+	g.names[name] = true
+	return name
+}
+
+func (g *generator) typeOf(s *genShape, hint, parent string) string {
+	switch s.kinds {
+	case 0:
+		return "any"
+	case genBool:
+		return "bool"
+	case genInt:
+		return "int64"
+	case genInt | genFloat, genFloat:
+		return "float64"
+	case genString:
+		if s.times == s.strings {
+			g.usesTime = true
+			return "time.Time"
+		}
+		return "string"
+	case genTime, genTime | genString:
+		if s.times == s.strings {
+			g.usesTime = true
+			return "time.Time"
+		}
+	case genArray:
+		return "[]" + g.typeOf(s.elem, singular(hint), parent)
+	case genObject:
+		name := g.typeName(initialisms(hint), parent)
+		g.declare(name, s)
+		return name
+	}
+	return "any"
+}
+
+// declare appends the struct declaration of s, nested types follow it.
+func (g *generator) declare(name string, s *genShape) {
+	var buf bytes.Buffer
+	at := len(g.types)
+	g.types = append(g.types, "")
+	fmt.Fprintf(&buf, "type %s struct {\n", name)
+	keys := MapKeys(s.fields)
+	sort.Strings(keys)
+	used := map[string]bool{}
+	for _, k := range keys {
+		f := s.fields[k]
+		field := initialisms(goFieldName(k))
+		for x := 2; used[field]; x++ {
+			field = fmt.Sprintf("%s%d", initialisms(goFieldName(k)), x)
+		}
+		used[field] = true
+		typ := g.typeOf(f, goFieldName(k), name)
+		tag := k
+		if f.count < s.objects || f.null {
+			tag += ",omitempty"
+			if typ != "any" && !strings.HasPrefix(typ, "[]") {
+				typ = "*" + typ
+			}
+		}
+		fmt.Fprintf(&buf, "\t%s %s `%s:%q`\n", field, typ, g.opt.Tag, tag)
+	}
+	buf.WriteString("}\n\n")
+	g.types[at] = buf.String()
+}
+
+// commonInitialisms are the words written in upper case in Go names, as
+// golint and staticcheck expect.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true, "DNS": true,
+	"EOF": true, "GUID": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "LHS": true, "QPS": true, "RAM": true, "RHS": true,
+	"RPC": true, "SLA": true, "SMTP": true, "SQL": true, "SSH": true, "TCP": true,
+	"TLS": true, "TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true, "XMPP": true,
+	"XSRF": true, "XSS": true,
+}
+
+// initialisms upper cases the common initialisms among the words of a camel
+// case name, plurals included, ie: "UserId" -> "UserID", "HttpUrls" ->
+// "HTTPURLs".
+func initialisms(name string) string {
+	var b strings.Builder
+	start := 0
+	flush := func(end int) {
+		w := name[start:end]
+		if commonInitialisms[strings.ToUpper(w)] {
+			b.WriteString(strings.ToUpper(w))
+		} else if plural := strings.TrimSuffix(w, "s"); plural != w && commonInitialisms[strings.ToUpper(plural)] {
+			b.WriteString(strings.ToUpper(plural) + "s")
+		} else {
+			b.WriteString(w)
+		}
+		start = end
+	}
+	for x := 1; x < len(name); x++ {
+		if c, prev := name[x], name[x-1]; c >= 'A' && c <= 'Z' && !(prev >= 'A' && prev <= 'Z') {
+			flush(x)
+		}
+	}
+	flush(len(name))
+	return b.String()
+}
+
+// singular is a naive English singular, ie: "Users" -> "User".
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return name[:len(name)-3] + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name
+	case strings.HasSuffix(name, "s") && len(name) > 1:
+		return name[:len(name)-1]
+	}
+	return name
+}
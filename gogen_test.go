@@ -0,0 +1,80 @@
+package godao
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateGo(t *testing.T) {
+	src := `{
+		"id": 1,
+		"name": "svc",
+		"ratio": 1.0,
+		"created": "2022-01-02T03:04:05Z",
+		"owner": {"id": 2, "email": null},
+		"users": [
+			{"id": 1, "name": "a", "address": {"zip": "1"}},
+			{"id": 2, "active": true}
+		],
+		"tags": [],
+		"mixed": [1, "a"]
+	}`
+	out, err := GenerateGo(src, GenerateOptions{Package: "api", Name: "Service"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "package api\n\n" +
+		"import \"time\"\n\n" +
+		"type Service struct {\n" +
+		"\tCreated time.Time `json:\"created\"`\n" +
+		"\tID      int64     `json:\"id\"`\n" +
+		"\tMixed   []any     `json:\"mixed\"`\n" +
+		"\tName    string    `json:\"name\"`\n" +
+		"\tOwner   Owner     `json:\"owner\"`\n" +
+		"\tRatio   float64   `json:\"ratio\"`\n" +
+		"\tTags    []any     `json:\"tags\"`\n" +
+		"\tUsers   []User    `json:\"users\"`\n" +
+		"}\n\n" +
+		"type Owner struct {\n" +
+		"\tEmail any   `json:\"email,omitempty\"`\n" +
+		"\tID    int64 `json:\"id\"`\n" +
+		"}\n\n" +
+		"type User struct {\n" +
+		"\tActive  *bool    `json:\"active,omitempty\"`\n" +
+		"\tAddress *Address `json:\"address,omitempty\"`\n" +
+		"\tID      int64    `json:\"id\"`\n" +
+		"\tName    *string  `json:\"name,omitempty\"`\n" +
+		"}\n\n" +
+		"type Address struct {\n" +
+		"\tZip string `json:\"zip\"`\n" +
+		"}\n"
+	if string(out) != want {
+		t.Errorf("GenerateGo =\n%s\nwant\n%s", out, want)
+	}
+
+	out, err = GenerateGo([]any{Map{"id": 1}, Map{"id": 2.5}}, GenerateOptions{Name: "Items"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "type Items []Item\n") || !strings.Contains(string(out), "ID float64 `json:\"id\"`") {
+		t.Errorf("GenerateGo(array) =\n%s", out)
+	}
+
+	out, err = GenerateGo(Map{"home_url": "x", "http_server": Map{"api_key": "k"}, "user_ids": []any{1}, "utf8": true, "identity": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"HomeURL ", "HTTPServer HTTPServer ", "UserIDs    []int64 ", "UTF8 ", "Identity ", "APIKey "} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("GenerateGo(initialisms) misses %q:\n%s", want, out)
+		}
+	}
+
+	out, err = GenerateGo("{\n  a: 1\n  b: [\n    x\n    y\n  ]\n}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "B []string `json:\"b\"`") {
+		t.Errorf("GenerateGo(hjson) =\n%s", out)
+	}
+}
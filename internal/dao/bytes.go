@@ -1 +1,8 @@
 package dao
+
+// BytesValue is the byte form of a Value.
+type BytesValue []byte
+
+func (b BytesValue) String() string {
+	return string(b)
+}
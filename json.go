@@ -1,6 +1,10 @@
 package godao
 
-import "github.com/hyprstereo/go-dao/encoding/json"
+import (
+	"io"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
 
 func JsonEncode(v any, pretty ...bool) (data []byte) {
 	return json.Encode(v, pretty...)
@@ -9,3 +13,24 @@ func JsonEncode(v any, pretty ...bool) (data []byte) {
 func JsonDecode(data []byte, v any) (err error) {
 	return json.Decode(data, v)
 }
+
+// StreamMaps calls fn with each object of the array at path in r, see
+// json.Decoder.Enter. Only one element is held in memory at a time.
+func StreamMaps(r io.Reader, path string, fn func(Map) error) (err error) {
+	d := json.NewDecoder(r)
+	if err = d.Enter(path); err != nil {
+		return
+	}
+	for {
+		var m Map
+		if err = d.Next(&m); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		if err = fn(m); err != nil {
+			return
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"github.com/hyprstereo/go-dao/encoding/hjson"
 	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/hyprstereo/go-dao/encoding/xml"
 	"github.com/hyprstereo/go-dao/utils"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -24,6 +25,9 @@ var mu sync.RWMutex
 
 type Result struct {
 	gjson.Result
+	val    any
+	native bool
+	path   string
 }
 
 func (r Result) fromBytes(data []byte) (rs Result) {
@@ -32,8 +36,37 @@ func (r Result) fromBytes(data []byte) (rs Result) {
 	return
 }
 
-func (r Result) Get(key string) Result {
-	return Result{Result: r.Result.Get(key)}
+func (r Result) Get(key string) (res Result) {
+	if r.native {
+		if segs, ok := parsePath(key); ok {
+			if v, ok := getPath(r.val, segs); ok {
+				res = newResult(v)
+			}
+		} else {
+			res = Result{Result: r.Result.Get(key)}
+		}
+	} else {
+		res = Result{Result: r.Result.Get(key)}
+	}
+	// the full path, for the errors of the typed getters
+	res.path = key
+	if r.path != "" && r.path != "@this" {
+		res.path = r.path + "." + key
+	}
+	return
+}
+
+// Value returns the value at the result. Results resolved from a Map
+// keep the stored value and its type.
+func (r Result) Value() any {
+	if r.native {
+		return r.val
+	}
+	return r.Result.Value()
+}
+
+func (r Result) Exists() bool {
+	return r.native || r.Result.Exists()
 }
 
 func (r Result) Bytes() []byte {
@@ -46,7 +79,8 @@ func (r Result) Decode(v any) (err error) {
 }
 
 func (r Result) Map() Map {
-	return Map(r.Value().(map[string]any))
+	obj, _ := asObject(r.Value())
+	return Map(obj)
 }
 
 func (r Result) Set(p string, value any) (err error) {
@@ -167,13 +201,10 @@ func (m Map) Each(fn func(int, string, any) bool) (a []any) {
 	return
 }
 
+// Selective returns a projection of m, see Project. Invalid expressions are
+// skipped.
 func (m Map) Selective(keys ...string) (o Map) {
-	mu.Lock()
-	defer mu.Unlock()
-	o = make(Map)
-	for k, val := range m {
-		o[k] = val
-	}
+	o, _ = m.project(keys, true)
 	return
 }
 
@@ -239,15 +270,18 @@ func (m Map) Call(key string, args ...any) (out any, err error) {
 	return
 }
 
+// GetByIndex returns the i-th entry in map iteration order, which is random.
+// Use an OrderedMap for a stable order.
 func (m Map) GetByIndex(i int) (k string, v any) {
 	mu.RLock()
+	defer mu.RUnlock()
 	c := 0
-	for _, v := range m {
+	for k, v := range m {
 		if c == i {
 			return k, v
 		}
+		c++
 	}
-	mu.RUnlock()
 	return "", nil
 }
 
@@ -316,24 +350,24 @@ func (m Map) EqualKeys(src Map) (r bool) {
 	return
 }
 
+// Diffs returns the sorted top level keys found in only one of the maps.
+// Use Diff for a recursive comparison.
 func (m Map) Diffs(src Map) (diff []string) {
 	mu.RLock()
 	defer mu.RUnlock()
 
 	diff = make([]string, 0)
-	k := m.Keys()
-	k2 := src.Keys()
-
-	sort.Strings(k)
-	sort.Strings(k2)
-
-	diffKeys := []string{}
-	for x, v := range k {
-		if v != k2[x] {
-			diffKeys = append(diffKeys, v)
+	for k := range m {
+		if _, ok := src[k]; !ok {
+			diff = append(diff, k)
+		}
+	}
+	for k := range src {
+		if _, ok := m[k]; !ok {
+			diff = append(diff, k)
 		}
 	}
-	diff = diffKeys
+	sort.Strings(diff)
 	return
 }
 
@@ -346,18 +380,29 @@ func (m Map) Merge(s ...Map) Map {
 	return m
 }
 
+// MergeStrict copies the top level keys of s that match none of the
+// excludes patterns, see Match.
 func (m Map) MergeStrict(s Map, excludes []string) Map {
-	ex := strings.Join(excludes, " ")
 	for n, v := range s {
-		if !strings.Contains(ex, n) {
+		if !matchAny(n, excludes) {
 			m[n] = v
-		} else {
-			fmt.Printf("%s field is restricted", n)
 		}
 	}
 	return m
 }
 
+// Clone returns a deep copy of the map's objects and arrays.
+func (m Map) Clone() Map {
+	if m == nil {
+		return nil
+	}
+	c := make(Map, len(m))
+	for k, v := range m {
+		c[k] = cloneValue(v)
+	}
+	return c
+}
+
 func (m Map) Consume(v map[string]any) Map {
 	m = Map(v)
 	return m
@@ -375,9 +420,8 @@ func (m Map) Has(keys ...string) bool {
 }
 
 func (m Map) Map(key string) Map {
-	if m.Get(key).Exists() {
-		//fmt.Println(m[key])
-		return Map(m[key].(Map))
+	if r := m.Get(key); r.Exists() {
+		return r.Map()
 	}
 	return nil
 }
@@ -412,15 +456,23 @@ func (m Map) Bool(key string) bool {
 	return false
 }
 
+// Dom renders m as the attributes of a tag element, inner is inserted as is.
+//
+// Deprecated: use Map.XML.
 func (m Map) Dom(tag string, inner ...string) (res string) {
-	mu.Lock()
-	defer mu.Unlock()
-	attrs := []string{}
-	for key, val := range m {
-		attrs = append(attrs, key+`="`+fmt.Sprint(val)+`"`)
+	mu.RLock()
+	defer mu.RUnlock()
+	attrs := make(Map, len(m))
+	for k, v := range m {
+		attrs["@"+k] = v
+	}
+	data, err := xml.Encode(Map{tag: attrs})
+	if err != nil {
+		return
 	}
-	dom := fmt.Sprintf("<%[1]s %[2]s>%[3]s</%[1]s>", tag, strings.Join(attrs, " "), strings.Join(inner, " "))
-	res = dom
+	// data is a self contained element, ie: <tag a="1"></tag>
+	end := "</" + tag + ">"
+	res = strings.TrimSuffix(string(data), end) + strings.Join(inner, " ") + end
 	return
 }
 
@@ -468,20 +520,30 @@ func (m Map) HJSON(keys ...string) interface{} {
 	return string(byt)
 }
 
+// Get resolves a gjson style path. Plain paths, wildcards and # are walked
+// on the map itself; modifiers, pipes and queries use the encoded bytes.
 func (m Map) Get(p string, defaultValue ...any) (res Result) {
 	mu.RLock()
 	defer mu.RUnlock()
+	return m.get(p, defaultValue...)
+}
+
+func (m Map) get(p string, defaultValue ...any) (res Result) {
 	if len(m) > 0 {
-		if p == "" {
-			p = "@this"
+		if p == "" || p == "@this" {
+			res = newResult(m)
+		} else if segs, ok := parsePath(p); ok {
+			if v, ok := getPath(m, segs); ok {
+				res = newResult(v)
+			}
+		} else {
+			res = Result{Result: gjson.GetBytes(m.Bytes(), p)}
 		}
-		res = Result{Result: gjson.GetBytes(m.Bytes(), p)}
 	}
 	if len(defaultValue) > 0 && !res.Exists() {
-		data, _ := sjson.Set("{}", p, defaultValue[0])
-		res.Value()
-		res = Result{Result: gjson.Parse(data)}
+		res = newResult(defaultValue[0])
 	}
+	res.path = p
 	return
 }
 
@@ -492,29 +554,56 @@ func (m Map) Interface(p string) (res any) {
 	return
 }
 
+// Set stores value at path p, creating missing parents, and returns the
+// stored value, or nil when the path cannot be set.
 func (m Map) Set(p string, value interface{}) (res any) {
 	mu.Lock()
 	defer mu.Unlock()
-	buf, er := sjson.SetBytes(m.Bytes(), p, value)
-	if er != nil {
-		//Result(gjson.ParseBytes(json.Encode(Map{"Error": er.Error()})))
-	} else {
-		json.Decode(buf, &m)
-		//res = Result(gjson.ParseBytes(buf))
-		res = string(m.Bytes())
+	if m.set(p, value) == nil {
+		res = value
 	}
 	return
 }
 
+func (m Map) set(p string, value any) (err error) {
+	if segs, ok := parsePath(p); ok && settable(segs) {
+		_, err = setPath(m, segs, value)
+		return
+	}
+	buf, err := sjson.SetBytes(m.Bytes(), p, value)
+	if err == nil {
+		err = json.Decode(buf, &m)
+	}
+	return
+}
+
+// Del removes path p and returns the removed value.
 func (m Map) Del(p string) (res Result) {
 	mu.Lock()
 	defer mu.Unlock()
+	res, _ = m.del(p)
+	return
+}
+
+func (m Map) del(p string) (res Result, ok bool) {
+	if segs, native := parsePath(p); native && settable(segs) {
+		var old any
+		if _, old, ok = delPath(m, segs); ok {
+			res = newResult(old)
+		}
+		return
+	}
+	old := gjson.GetBytes(m.Bytes(), p)
 	buf, er := sjson.DeleteBytes(m.Bytes(), p)
 	if er != nil {
 		res = Result{Result: gjson.ParseBytes(json.Encode(Map{"Error": er.Error()}))}
 	} else {
+		for k := range m {
+			delete(m, k)
+		}
 		json.Decode(buf, &m)
-		res = Result{Result: gjson.ParseBytes(buf)}
+		res = Result{Result: old}
+		ok = old.Exists()
 	}
 	return
 }
@@ -570,24 +659,21 @@ func (m Map) Iterate(from string, cb func(string, Result) bool) (result Map) {
 	return
 }
 
-// decode the values into any variable
+// decode the values into any variable, dst must be a pointer
 func (m Map) Decode(key string, dst any) (err error) {
 	d := m.Get(key)
-	if d.Exists() {
-		switch d.Type {
-		case gjson.Number:
-			dst = d.Num
-		case gjson.String:
-			dst = d.String()
-		case gjson.False, gjson.True:
-			dst = d.Bool()
-		case gjson.JSON:
-			err = json.Decode([]byte(d.Raw), dst)
-		default:
-			err = json.Decode([]byte(d.Raw), dst)
-		}
-
+	if !d.Exists() {
+		return &PathError{Path: key, Type: fmt.Sprintf("%T", dst), Err: ErrNotFound}
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode %s: non-pointer %T", key, dst)
+	}
+	if v := reflect.ValueOf(d.Value()); v.IsValid() && v.Type().AssignableTo(rv.Elem().Type()) {
+		rv.Elem().Set(v)
+		return
 	}
+	err = json.Decode([]byte(d.Raw), dst)
 	return
 }
 
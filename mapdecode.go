@@ -0,0 +1,385 @@
+package godao
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeHook converts a raw value before it is decoded into a target of the
+// type it is registered for.
+type DecodeHook func(v any) (any, error)
+
+// DecodeOptions configures Map.DecodeInto.
+type DecodeOptions struct {
+	// TagName selects the struct tag holding field names. By default the
+	// dao tag is used, then the json tag.
+	TagName string
+	// WeaklyTyped converts between kinds, ie: "1" -> 1, "1.5s" -> time.Duration.
+	WeaklyTyped bool
+	// ErrorUnused fails when a key has no matching struct field.
+	ErrorUnused bool
+	// Hooks run for targets of the given type, before decoding.
+	Hooks map[reflect.Type]DecodeHook
+}
+
+var (
+	ErrUnknownField = errors.New("unknown field")
+
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	unmarshaler  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	marshaler    = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// DecodeInto fills dst, a pointer, from m without going through JSON. Struct
+// fields are matched by tag name, then case-insensitively by field name;
+// embedded structs and fields tagged ",inline" are filled from the same level.
+func (m Map) DecodeInto(dst any, opts ...DecodeOptions) error {
+	var opt DecodeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("decode: non-pointer %T", dst)
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	d := &decoder{opt: opt}
+	if opt.WeaklyTyped {
+		d.mode = CoerceLenient
+	} else {
+		d.mode = CoerceStrict
+	}
+	return d.decode(map[string]any(m), rv.Elem(), "")
+}
+
+type decoder struct {
+	opt  DecodeOptions
+	mode CoerceMode
+}
+
+func (d *decoder) fail(path string, t reflect.Type, v any, err error) error {
+	if path == "" {
+		path = "@this"
+	}
+	var pe *PathError
+	if errors.As(err, &pe) {
+		return err
+	}
+	return &PathError{Path: path, Type: t.String(), Value: v, Err: err}
+}
+
+func (d *decoder) decode(v any, rv reflect.Value, path string) (err error) {
+	t := rv.Type()
+	if hook, ok := d.opt.Hooks[t]; ok {
+		if v, err = hook(v); err != nil {
+			return d.fail(path, t, v, err)
+		}
+	}
+	if v == nil {
+		rv.Set(reflect.Zero(t))
+		return nil
+	}
+	src := reflect.ValueOf(v)
+	if src.Type() == t {
+		rv.Set(src)
+		return nil
+	}
+	if t.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(t.Elem()))
+		}
+		return d.decode(v, rv.Elem(), path)
+	}
+	switch t {
+	case timeType:
+		tm, err := toTime(v, d.mode)
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.Set(reflect.ValueOf(tm))
+		return nil
+	case durationType:
+		dur, err := toDuration(v, d.mode)
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetInt(int64(dur))
+		return nil
+	}
+	if s, ok := v.(string); ok && reflect.PointerTo(t).Implements(unmarshaler) {
+		if err = rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return d.fail(path, t, v, err)
+		}
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if !src.Type().AssignableTo(t) {
+			return d.fail(path, t, v, ErrInvalidValue)
+		}
+		rv.Set(src)
+	case reflect.Bool:
+		b, err := toBool(v, d.mode)
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := toInt64(v, d.mode)
+		if err == nil && rv.OverflowInt(n) {
+			err = fmt.Errorf("value overflows %s", t)
+		}
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := toInt64(v, d.mode)
+		if err == nil && (n < 0 || rv.OverflowUint(uint64(n))) {
+			err = fmt.Errorf("value overflows %s", t)
+		}
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetUint(uint64(n))
+	case reflect.Float32, reflect.Float64:
+		f, err := toFloat64(v, d.mode)
+		if err == nil && rv.OverflowFloat(f) {
+			err = fmt.Errorf("value overflows %s", t)
+		}
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetFloat(f)
+	case reflect.String:
+		s, err := toString(v, d.mode)
+		if err != nil {
+			return d.fail(path, t, v, err)
+		}
+		rv.SetString(s)
+	case reflect.Struct:
+		obj, ok := asObject(v)
+		if !ok && d.mode == CoerceLenient {
+			obj, ok = asObject(toGeneric(v))
+		}
+		if !ok {
+			return d.fail(path, t, v, ErrInvalidValue)
+		}
+		return d.decodeStruct(obj, rv, path)
+	case reflect.Map:
+		return d.decodeMap(v, rv, path)
+	case reflect.Slice:
+		if s, ok := v.(string); ok && t.Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes([]byte(s))
+			return nil
+		}
+		arr, ok := asArray(v)
+		if !ok {
+			if d.mode == CoerceStrict {
+				return d.fail(path, t, v, ErrInvalidValue)
+			}
+			arr = []any{v}
+		}
+		out := reflect.MakeSlice(t, len(arr), len(arr))
+		for x, e := range arr {
+			if err = d.decode(e, out.Index(x), joinPath(path, strconv.Itoa(x))); err != nil {
+				return err
+			}
+		}
+		rv.Set(out)
+	case reflect.Array:
+		arr, ok := asArray(v)
+		if !ok || len(arr) > t.Len() {
+			return d.fail(path, t, v, ErrInvalidValue)
+		}
+		for x, e := range arr {
+			if err = d.decode(e, rv.Index(x), joinPath(path, strconv.Itoa(x))); err != nil {
+				return err
+			}
+		}
+	default:
+		if !src.Type().ConvertibleTo(t) {
+			return d.fail(path, t, v, ErrInvalidValue)
+		}
+		rv.Set(src.Convert(t))
+	}
+	return nil
+}
+
+func (d *decoder) decodeMap(v any, rv reflect.Value, path string) error {
+	t := rv.Type()
+	obj, ok := asObject(v)
+	if !ok && d.mode == CoerceLenient {
+		obj, ok = asObject(toGeneric(v))
+	}
+	if !ok {
+		return d.fail(path, t, v, ErrInvalidValue)
+	}
+	out := reflect.MakeMapWithSize(t, len(obj))
+	for k, e := range obj {
+		key := reflect.New(t.Key()).Elem()
+		if err := d.decode(k, key, joinPath(path, k)); err != nil {
+			return err
+		}
+		val := reflect.New(t.Elem()).Elem()
+		if err := d.decode(e, val, joinPath(path, k)); err != nil {
+			return err
+		}
+		out.SetMapIndex(key, val)
+	}
+	rv.Set(out)
+	return nil
+}
+
+func (d *decoder) decodeStruct(obj map[string]any, rv reflect.Value, path string) error {
+	fields := structFields(rv.Type(), d.opt.TagName)
+	used := make(map[string]bool, len(obj))
+	for _, f := range fields {
+		key, ok := f.name, false
+		var v any
+		if v, ok = obj[key]; !ok {
+			for k, e := range obj {
+				if !used[k] && strings.EqualFold(k, f.name) {
+					key, v, ok = k, e, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		used[key] = true
+		fv, err := fieldByIndexAlloc(rv, f.index)
+		if err != nil {
+			return d.fail(joinPath(path, key), rv.Type(), v, err)
+		}
+		if err := d.decode(v, fv, joinPath(path, key)); err != nil {
+			return err
+		}
+	}
+	if d.opt.ErrorUnused && len(used) < len(obj) {
+		keys := make([]string, 0)
+		for k := range obj {
+			if !used[k] {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		return &PathError{Path: joinPath(path, keys[0]), Type: rv.Type().String(), Value: obj[keys[0]], Err: ErrUnknownField}
+	}
+	return nil
+}
+
+// field describes a struct field as seen by Map conversions.
+type field struct {
+	name      string
+	tagged    bool // name comes from the struct tag
+	index     []int
+	omitEmpty bool
+	typ       reflect.Type
+}
+
+// structFields lists the fields of t by tag name, in declaration order.
+// Embedded structs without a name, and fields tagged ",inline", contribute
+// their own fields; a shallower field wins over a deeper one with the same name.
+func structFields(t reflect.Type, tagName string) (fields []field) {
+	type candidate struct {
+		field
+		depth int
+	}
+	var all []candidate
+	var walk func(t reflect.Type, index []int, depth int)
+	walk = func(t reflect.Type, index []int, depth int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := fieldTag(sf, tagName)
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			idx := append(append([]int{}, index...), i)
+			inline := strings.Contains(","+opts+",", ",inline,")
+			if ft.Kind() == reflect.Struct && ((sf.Anonymous && name == "") || inline) {
+				if depth < 8 {
+					walk(ft, idx, depth+1)
+				}
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+			tagged := name != ""
+			if !tagged {
+				name = sf.Name
+			}
+			all = append(all, candidate{field: field{
+				name:      name,
+				tagged:    tagged,
+				index:     idx,
+				omitEmpty: strings.Contains(","+opts+",", ",omitempty,"),
+				typ:       sf.Type,
+			}, depth: depth})
+		}
+	}
+	walk(t, nil, 0)
+	best := map[string]int{}
+	for x, c := range all {
+		if b, ok := best[c.name]; !ok || c.depth < all[b].depth {
+			best[c.name] = x
+		}
+	}
+	for x, c := range all {
+		if best[c.name] == x {
+			fields = append(fields, c.field)
+		}
+	}
+	return
+}
+
+func fieldTag(sf reflect.StructField, tagName string) string {
+	if tagName != "" {
+		return sf.Tag.Get(tagName)
+	}
+	if tag, ok := sf.Tag.Lookup("dao"); ok {
+		return tag
+	}
+	return sf.Tag.Get("json")
+}
+
+// fieldByIndexAlloc is reflect.Value.FieldByIndex, allocating nil embedded
+// pointers on the way.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, error) {
+	for x, i := range index {
+		if x > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return v, fmt.Errorf("cannot set embedded pointer to unexported %s", v.Type().Elem())
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, nil
+}
+
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return EscapePathKey(key)
+	}
+	return prefix + "." + EscapePathKey(key)
+}
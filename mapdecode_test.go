@@ -0,0 +1,83 @@
+package godao
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type decodeBase struct {
+	ID int64 `json:"id"`
+}
+
+type decodeOwner struct {
+	Name string `dao:"owner_name"`
+}
+
+type decodeTarget struct {
+	decodeBase
+	Title    string            `json:"title"`
+	Port     uint16            `json:"port"`
+	Ratio    *float64          `json:"ratio"`
+	Tags     []string          `json:"tags"`
+	Labels   map[string]int    `json:"labels"`
+	Timeout  time.Duration     `json:"timeout"`
+	Created  time.Time         `json:"created"`
+	Owner    *decodeOwner      `json:"owner"`
+	Extra    map[string]any    `json:"extra"`
+	Upper    string            `json:"upper"`
+	Skipped  string            `json:"-"`
+	Settings struct{ On bool } `json:"settings"`
+}
+
+func TestDecodeInto(t *testing.T) {
+	m := Map{
+		"id":       "7",
+		"title":    "svc",
+		"port":     "8080",
+		"ratio":    0.5,
+		"tags":     []any{"a", "b"},
+		"labels":   Map{"x": "1"},
+		"timeout":  "2s",
+		"created":  "2022-01-02T03:04:05Z",
+		"owner":    Map{"owner_name": "jo"},
+		"extra":    Map{"k": true},
+		"UPPER":    "case",
+		"Skipped":  "no",
+		"settings": Map{"on": "true"},
+	}
+	var dst decodeTarget
+	hooks := map[reflect.Type]DecodeHook{
+		reflect.TypeOf(""): func(v any) (any, error) {
+			if s, ok := v.(string); ok {
+				return strings.TrimSpace(s), nil
+			}
+			return v, nil
+		},
+	}
+	if err := m.DecodeInto(&dst, DecodeOptions{WeaklyTyped: true, Hooks: hooks}); err != nil {
+		t.Fatal(err)
+	}
+	if dst.ID != 7 || dst.Port != 8080 || *dst.Ratio != 0.5 || dst.Timeout != 2*time.Second ||
+		dst.Created.Year() != 2022 || dst.Owner.Name != "jo" || dst.Labels["x"] != 1 ||
+		dst.Upper != "case" || dst.Skipped != "" || !dst.Settings.On || len(dst.Tags) != 2 {
+		t.Errorf("decoded = %+v", dst)
+	}
+
+	if err := (Map{"created": "2022-03-01"}).DecodeInto(&dst, DecodeOptions{WeaklyTyped: true}); err != nil || dst.Created.Month() != time.March {
+		t.Errorf("date only created = %v, %v", dst.Created, err)
+	}
+
+	err := m.DecodeInto(&dst)
+	var pe *PathError
+	if !errors.As(err, &pe) || pe.Path != "id" {
+		t.Errorf("strict decode error = %v", err)
+	}
+
+	err = Map{"title": "x", "bogus": 1}.DecodeInto(&dst, DecodeOptions{ErrorUnused: true})
+	if !errors.Is(err, ErrUnknownField) {
+		t.Errorf("unknown field error = %v", err)
+	}
+}
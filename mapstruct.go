@@ -1,8 +1,12 @@
 package godao
 
 import (
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/hyprstereo/go-dao/utils"
 	"github.com/iancoleman/strcase"
@@ -20,6 +24,7 @@ var (
 		"ARRAY":     reflect.TypeOf([]any{}),
 		"BLOB":      reflect.TypeOf([]byte{}),
 		"RUNE":      reflect.TypeOf(rune('0')),
+		"ANY":       reflect.TypeOf((*any)(nil)).Elem(),
 	}
 )
 
@@ -27,16 +32,230 @@ type MStructConfig struct {
 	Tag string
 }
 
+// MapToStruct builds a struct type from the values of src, fields are sorted
+// by key and tagged with it. Keys giving the same Go field name get a numeric suffix, ie: "a_b"
+// and "a-b" become AB and AB2.
 func MapToStruct(src Map, tags string) (newStruct reflect.Type) {
+	if tags == "" {
+		tags = "json"
+	}
+	keys := MapKeys(src)
+	sort.Strings(keys)
 	sFields := []reflect.StructField{}
-	for name, field := range src {
+	used := map[string]bool{}
+	for _, name := range keys {
+		field := src[name]
+		ft := preType["ANY"]
+		if obj, ok := asObject(field); ok {
+			ft = MapToStruct(obj, tags)
+		} else if field != nil {
+			ft = reflect.TypeOf(field)
+		}
+		fname := goFieldName(name)
+		for x := 2; used[fname]; x++ {
+			fname = fmt.Sprintf("%s%d", goFieldName(name), x)
+		}
+		used[fname] = true
 		st := reflect.StructField{
-			Name: strcase.ToCamel(utils.SafeString(name)),
-			Type: reflect.TypeOf(field),
-			Tag:  reflect.StructTag(`json:"` + strcase.ToLowerCamel(name) + `"`),
+			Name: fname,
+			Type: ft,
+			Tag:  reflect.StructTag(fmt.Sprintf(`%s:%q`, tags, name)),
 		}
 		sFields = append(sFields, st)
 	}
 	newStruct = reflect.StructOf(sFields)
 	return
 }
+
+// goFieldName converts a Map key into an exported Go identifier.
+func goFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return r
+		}
+		return -1
+	}, strcase.ToCamel(utils.SafeString(key)))
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// DynamicStruct is a struct type built at runtime from a schema Map.
+type DynamicStruct struct {
+	Type reflect.Type
+	tag  string
+}
+
+// StructFromSchema builds a struct type from a schema Map, where every value
+// is either a type name of the preType table ("STRING", "INT", "TIME"...), a
+// nested schema Map, or a one element array describing the items of a slice:
+//
+//	Map{"name": "STRING", "tags": []any{"STRING"}, "owner": Map{"id": "INT"}}
+//
+// A type name ending with "?" becomes a pointer, ie: "INT?" -> *int32. Fields
+// are sorted by key and tagged with their key, using the "json" tag unless
+// the config sets another one.
+func StructFromSchema(schema Map, cfg ...MStructConfig) (ds *DynamicStruct, err error) {
+	ds = &DynamicStruct{tag: "json"}
+	if len(cfg) > 0 && cfg[0].Tag != "" {
+		ds.tag = cfg[0].Tag
+	}
+	if ds.Type, err = ds.build(schema, ""); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// MustStructFromSchema is StructFromSchema, panicking on error.
+func MustStructFromSchema(schema Map, cfg ...MStructConfig) *DynamicStruct {
+	ds, err := StructFromSchema(schema, cfg...)
+	if err != nil {
+		panic(err)
+	}
+	return ds
+}
+
+func (ds *DynamicStruct) build(schema any, path string) (reflect.Type, error) {
+	switch val := schema.(type) {
+	case string:
+		name := strings.ToUpper(strings.TrimSpace(val))
+		optional := strings.HasSuffix(name, "?")
+		t, ok := preType[strings.TrimSuffix(name, "?")]
+		if !ok {
+			return nil, fmt.Errorf("schema %s: unknown type %q", orRoot(path), val)
+		}
+		if optional {
+			t = reflect.PointerTo(t)
+		}
+		return t, nil
+	case nil:
+		return nil, fmt.Errorf("schema %s: missing type", orRoot(path))
+	}
+	if obj, ok := asObject(schema); ok {
+		keys := MapKeys(obj)
+		sort.Strings(keys)
+		fields := make([]reflect.StructField, 0, len(keys))
+		names := map[string]string{}
+		for _, k := range keys {
+			ft, err := ds.build(obj[k], joinPath(path, k))
+			if err != nil {
+				return nil, err
+			}
+			name := goFieldName(k)
+			if other, ok := names[name]; ok {
+				return nil, fmt.Errorf("schema %s: keys %q and %q have the same field name %s", orRoot(path), other, k, name)
+			}
+			names[name] = k
+			fields = append(fields, reflect.StructField{
+				Name: name,
+				Type: ft,
+				Tag:  reflect.StructTag(fmt.Sprintf(`%s:%q`, ds.tag, k)),
+			})
+		}
+		return reflect.StructOf(fields), nil
+	}
+	if arr, ok := asArray(schema); ok {
+		if len(arr) != 1 {
+			return nil, fmt.Errorf("schema %s: array must hold a single item type", orRoot(path))
+		}
+		t, err := ds.build(arr[0], strings.TrimPrefix(path+".#", "."))
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(t), nil
+	}
+	return nil, fmt.Errorf("schema %s: invalid type %T", orRoot(path), schema)
+}
+
+func orRoot(path string) string {
+	if path == "" {
+		return "@this"
+	}
+	return path
+}
+
+// New returns a pointer to a zero value of the struct.
+func (ds *DynamicStruct) New() any {
+	return reflect.New(ds.Type).Interface()
+}
+
+// Fill returns a pointer to a new struct decoded from data, see Map.DecodeInto.
+// Decoding is strict unless options are given.
+func (ds *DynamicStruct) Fill(data Map, opts ...DecodeOptions) (v any, err error) {
+	opt := DecodeOptions{}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TagName == "" {
+		opt.TagName = ds.tag
+	}
+	v = ds.New()
+	if err = data.DecodeInto(v, opt); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// FieldErrors holds every incompatible field found by Validate.
+type FieldErrors []*PathError
+
+func (e FieldErrors) Error() string {
+	msgs := make([]string, len(e))
+	for x, err := range e {
+		msgs[x] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks field by field that data can be stored in the struct
+// without conversion and has no unknown keys, the returned error is a
+// FieldErrors. Missing keys are allowed.
+func (ds *DynamicStruct) Validate(data Map) error {
+	mu.RLock()
+	defer mu.RUnlock()
+	d := &decoder{opt: DecodeOptions{TagName: ds.tag}, mode: CoerceStrict}
+	var errs FieldErrors
+	d.validate(map[string]any(data), ds.Type, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *decoder) validate(v any, t reflect.Type, path string, errs *FieldErrors) {
+	if t.Kind() == reflect.Pointer && v != nil {
+		t = t.Elem()
+	}
+	if obj, ok := asObject(v); ok && t.Kind() == reflect.Struct && t != timeType {
+		fields := structFields(t, d.opt.TagName)
+		byName := make(map[string]field, len(fields))
+		for _, f := range fields {
+			byName[f.name] = f
+		}
+		keys := MapKeys(obj)
+		sort.Strings(keys)
+		for _, k := range keys {
+			f, ok := byName[k]
+			if !ok {
+				*errs = append(*errs, &PathError{Path: joinPath(path, k), Type: t.String(), Value: obj[k], Err: ErrUnknownField})
+				continue
+			}
+			d.validate(obj[k], f.typ, joinPath(path, k), errs)
+		}
+		return
+	}
+	if arr, ok := asArray(v); ok && t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 {
+		for x, e := range arr {
+			d.validate(e, t.Elem(), joinPath(path, fmt.Sprint(x)), errs)
+		}
+		return
+	}
+	if err := d.decode(v, reflect.New(t).Elem(), path); err != nil {
+		if pe, ok := err.(*PathError); ok {
+			*errs = append(*errs, pe)
+		} else {
+			*errs = append(*errs, &PathError{Path: orRoot(path), Type: t.String(), Value: v, Err: err})
+		}
+	}
+}
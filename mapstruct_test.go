@@ -17,10 +17,10 @@ func TestMapToStruct(t *testing.T) {
 	v := MapToStruct(m, "json")
 	newStruct := reflect.New(v).Elem()
 
-	newStruct.Field(0).SetInt(1)
-	newStruct.Field(1).SetString("Name")
-	newStruct.Field(2).Set(reflect.ValueOf(time.Now()))
-	newStruct.Field(3).SetBool(true)
+	newStruct.Field(0).Set(reflect.ValueOf(time.Now()))
+	newStruct.Field(1).SetInt(1)
+	newStruct.Field(2).SetBool(true)
+	newStruct.Field(3).SetString("Name")
 
 	d := newStruct.Addr().Interface()
 	str := strings.ReplaceAll(reflect.TypeOf(d).String(), `"j`, "`j")
@@ -28,3 +28,78 @@ func TestMapToStruct(t *testing.T) {
 	str = strings.ReplaceAll(str, `\"`, `"`)
 	println(str)
 }
+
+func TestMapToStructNested(t *testing.T) {
+	v := MapToStruct(Map{"missing": nil, "owner": Map{"id": 1}}, "")
+	f, ok := v.FieldByName("Owner")
+	if !ok || f.Type.Kind() != reflect.Struct || f.Tag.Get("json") != "owner" {
+		t.Fatalf("Owner field = %v", f)
+	}
+	if f, _ := v.FieldByName("Missing"); f.Type.Kind() != reflect.Interface {
+		t.Errorf("nil value field type = %v", f.Type)
+	}
+
+	v = MapToStruct(Map{"a_b": 1, "a-b": "x", "AB2": true}, "")
+	if v.NumField() != 3 || v.Field(0).Name != "AB2" || v.Field(1).Name != "AB" || v.Field(2).Name != "AB3" {
+		t.Errorf("colliding keys = %v", v)
+	}
+	if v.Field(1).Tag.Get("json") != "a-b" || v.Field(2).Tag.Get("json") != "a_b" {
+		t.Errorf("colliding keys tags = %v", v)
+	}
+}
+
+func TestStructFromSchema(t *testing.T) {
+	ds, err := StructFromSchema(Map{
+		"name":   "STRING",
+		"age":    "int?",
+		"tags":   []any{"STRING"},
+		"owner":  Map{"id": "INT", "since": "TIME"},
+		"pets":   []any{Map{"kind": "STRING"}},
+		"2fa-on": "BOOL",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "struct { X2FaOn bool \"json:\\\"2fa-on\\\"\"; Age *int32 \"json:\\\"age\\\"\"; Name string \"json:\\\"name\\\"\"; Owner struct { Id int32 \"json:\\\"id\\\"\"; Since time.Time \"json:\\\"since\\\"\" } \"json:\\\"owner\\\"\"; Pets []struct { Kind string \"json:\\\"kind\\\"\" } \"json:\\\"pets\\\"\"; Tags []string \"json:\\\"tags\\\"\" }"
+	if got := ds.Type.String(); got != want {
+		t.Errorf("type =\n%s\nwant\n%s", got, want)
+	}
+
+	data := Map{
+		"name":  "rex",
+		"age":   float64(3),
+		"tags":  []any{"a", "b"},
+		"owner": Map{"id": 7, "since": "2022-01-02T03:04:05Z"},
+		"pets":  []any{Map{"kind": "cat"}},
+	}
+	if err := ds.Validate(data); err != nil {
+		t.Fatalf("Validate = %v", err)
+	}
+	v, err := ds.Fill(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv := reflect.ValueOf(v).Elem()
+	if rv.FieldByName("Age").Elem().Int() != 3 || rv.FieldByName("Owner").FieldByName("Id").Int() != 7 ||
+		rv.FieldByName("Pets").Index(0).FieldByName("Kind").String() != "cat" {
+		t.Errorf("Fill = %+v", v)
+	}
+
+	err = ds.Validate(Map{"name": 1, "tags": []any{"a", 2}, "owner": Map{"id": "x", "extra": 1}})
+	errs, ok := err.(FieldErrors)
+	if !ok || len(errs) != 4 {
+		t.Fatalf("Validate = %v", err)
+	}
+	paths := []string{"name", "owner.extra", "owner.id", "tags.1"}
+	for x, e := range errs {
+		if e.Path != paths[x] {
+			t.Errorf("error %d path = %s, want %s", x, e.Path, paths[x])
+		}
+	}
+
+	for _, bad := range []Map{{"x": "NOPE"}, {"x": []any{}}, {"x": nil}, {"a_b": "INT", "a-b": "INT"}} {
+		if _, err := StructFromSchema(bad); err == nil {
+			t.Errorf("StructFromSchema(%v) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package godao
+
+import (
+	"strconv"
+)
+
+// MergeStrategy decides how MergeDeep combines an existing and an incoming value.
+type MergeStrategy uint8
+
+const (
+	// MergeReplace merges objects recursively and replaces everything else.
+	MergeReplace MergeStrategy = iota
+	// MergeAppend appends incoming arrays to existing ones.
+	MergeAppend
+	// MergeUnion merges arrays of objects by the rule's Key field, appending
+	// objects whose key is not present yet.
+	MergeUnion
+	// MergeKeepExisting keeps the existing value when there is one.
+	MergeKeepExisting
+	// MergeConflict asks MergeOptions.OnConflict when the values differ.
+	MergeConflict
+)
+
+// MergeRule applies a strategy to the paths matching Path, see Match.
+type MergeRule struct {
+	Path     string
+	Strategy MergeStrategy
+	// Key identifies objects for MergeUnion, ie: "id".
+	Key string
+}
+
+// MergeOptions configures MergeDeep. Paths are the keys joined by dots,
+// without escaping, ie: "db.hosts".
+type MergeOptions struct {
+	// Default is used where no rule matches.
+	Default MergeStrategy
+	// Rules are tested in order, the first match wins.
+	Rules []MergeRule
+	// Exclude skips the incoming paths matching any of these patterns.
+	Exclude []string
+	// OnConflict returns the value to keep for MergeConflict paths.
+	OnConflict func(path string, existing, incoming any) any
+}
+
+func (o *MergeOptions) rule(path string) MergeRule {
+	for _, r := range o.Rules {
+		if Match(path, r.Path) {
+			return r
+		}
+	}
+	return MergeRule{Path: path, Strategy: o.Default}
+}
+
+func matchAny(str string, patterns []string) bool {
+	for _, p := range patterns {
+		if Match(str, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeDeep merges src into m recursively and returns m. Incoming values are
+// copied, so later changes to src do not leak into m.
+func (m Map) MergeDeep(src Map, opts ...MergeOptions) Map {
+	var opt MergeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	mergeObject(m, src, "", &opt)
+	return m
+}
+
+func mergeObject(dst, src map[string]any, prefix string, opt *MergeOptions) {
+	for k, v := range src {
+		path := prefix + k
+		if matchAny(path, opt.Exclude) {
+			continue
+		}
+		existing, found := dst[k]
+		if !found {
+			dst[k] = cloneValue(v)
+			continue
+		}
+		dst[k] = mergeValue(existing, v, path, opt)
+	}
+}
+
+func mergeValue(existing, incoming any, path string, opt *MergeOptions) any {
+	rule := opt.rule(path)
+	switch rule.Strategy {
+	case MergeKeepExisting:
+		return existing
+	case MergeConflict:
+		if jsonEqual(existing, incoming) {
+			return existing
+		}
+		if opt.OnConflict != nil {
+			return cloneValue(opt.OnConflict(path, existing, incoming))
+		}
+		return cloneValue(incoming)
+	}
+	if dst, ok := asObject(existing); ok {
+		if src, ok := asObject(incoming); ok {
+			mergeObject(dst, src, path+".", opt)
+			return existing
+		}
+	}
+	if dst, ok := asArray(existing); ok {
+		if src, ok := asArray(incoming); ok {
+			switch rule.Strategy {
+			case MergeAppend:
+				return append(append([]any{}, dst...), cloneValue(src).([]any)...)
+			case MergeUnion:
+				return mergeUnion(dst, src, path, rule.Key, opt)
+			}
+		}
+	}
+	return cloneValue(incoming)
+}
+
+// mergeUnion merges objects sharing the same key value and appends the rest.
+func mergeUnion(dst, src []any, path, key string, opt *MergeOptions) []any {
+	out := append([]any{}, dst...)
+	index := map[any]int{}
+	for x, e := range out {
+		if id, ok := unionID(e, key); ok {
+			index[id] = x
+		}
+	}
+	for _, e := range src {
+		id, ok := unionID(e, key)
+		if x, found := index[id]; ok && found {
+			out[x] = mergeValue(out[x], e, path+"."+strconv.Itoa(x), opt)
+			continue
+		}
+		out = append(out, cloneValue(e))
+		if ok {
+			index[id] = len(out) - 1
+		}
+	}
+	return out
+}
+
+// unionID returns the key field of an object element, numbers as float64
+// so that 1 and 1.0 identify the same element.
+func unionID(e any, key string) (any, bool) {
+	obj, ok := asObject(e)
+	if !ok {
+		return nil, false
+	}
+	switch id := obj[key].(type) {
+	case string, bool:
+		return id, true
+	case nil:
+		return nil, false
+	default:
+		if isNumber(id) {
+			f, _ := toFloat64(id, CoerceStrict)
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// MergePatch applies an RFC 7386 JSON Merge Patch to m and returns m:
+// null removes a key, objects merge recursively and anything else replaces.
+func (m Map) MergePatch(patch Map) Map {
+	mergePatch(m, patch)
+	return m
+}
+
+func mergePatch(dst, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+		p, ok := asObject(v)
+		if !ok {
+			dst[k] = cloneValue(v)
+			continue
+		}
+		target, ok := asObject(dst[k])
+		if !ok {
+			target = Map{}
+			dst[k] = target
+		}
+		mergePatch(target, p)
+	}
+}
@@ -0,0 +1,65 @@
+package godao
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeDeep(t *testing.T) {
+	m := Map{
+		"id":    1,
+		"guid":  "a",
+		"db":    Map{"host": "localhost", "port": 5432},
+		"tags":  []any{"a"},
+		"users": []any{Map{"id": 1, "name": "jo"}},
+		"mode":  "dev",
+	}
+	src := Map{
+		"id":    2,
+		"guid":  "b",
+		"db":    Map{"host": "db"},
+		"tags":  []any{"b"},
+		"users": []any{Map{"id": 1, "role": "admin"}, Map{"id": 2, "name": "al"}},
+		"mode":  "prod",
+	}
+	var conflicts []string
+	m.MergeDeep(src, MergeOptions{
+		Exclude: []string{"id"},
+		Rules: []MergeRule{
+			{Path: "tags", Strategy: MergeAppend},
+			{Path: "users", Strategy: MergeUnion, Key: "id"},
+			{Path: "mode", Strategy: MergeConflict},
+		},
+		OnConflict: func(path string, existing, incoming any) any {
+			conflicts = append(conflicts, path)
+			return existing
+		},
+	})
+
+	want := Map{
+		"id":   1,
+		"guid": "b",
+		"db":   Map{"host": "db", "port": 5432},
+		"tags": []any{"a", "b"},
+		"users": []any{
+			Map{"id": 1, "name": "jo", "role": "admin"},
+			Map{"id": 2, "name": "al"},
+		},
+		"mode": "dev",
+	}
+	if d := m.Diff(want); len(d) > 0 {
+		t.Errorf("merge result differs: %s", d.Bytes())
+	}
+	if !reflect.DeepEqual(conflicts, []string{"mode"}) {
+		t.Errorf("conflicts = %v", conflicts)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	m := Map{"title": "Goodbye!", "author": Map{"givenName": "John", "familyName": "Doe"}, "tags": []any{"example", "sample"}}
+	m.MergePatch(Map{"title": "Hello!", "phoneNumber": "+01-123-456-7890", "author": Map{"familyName": nil}, "tags": []any{"example"}})
+	want := Map{"title": "Hello!", "author": Map{"givenName": "John"}, "tags": []any{"example"}, "phoneNumber": "+01-123-456-7890"}
+	if d := m.Diff(want); len(d) > 0 {
+		t.Errorf("merge patch differs: %s", d.Bytes())
+	}
+}
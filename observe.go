@@ -0,0 +1,229 @@
+package godao
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hyprstereo/go-dao/internal/dao"
+)
+
+// Change describes a value that changed at Path. Exists and Existed tell
+// whether the path is present after and before the change.
+type Change struct {
+	Op      string
+	Path    string
+	Old     any
+	New     any
+	Existed bool
+	Exists  bool
+	// Tags holds the $tag values of the subscription pattern, ie: "id" for
+	// "users.$id.email".
+	Tags map[string]string
+}
+
+// Observer receives the changes matching its subscription.
+type Observer func(Change)
+
+type subscription struct {
+	pattern dao.Pattern
+	fn      Observer
+}
+
+// ObservableMap is a SyncMap that notifies subscribers when Set, Del or Merge
+// change a path matching their pattern. Patterns use resource style parts:
+//
+//	"users.*.email" // * matches a single part
+//	"features.>"    // > matches everything that follows
+//	"model.$id"     // $id matches a single part, reported in Change.Tags
+//
+// Writes report the written path and every changed value below it, a
+// subscriber only receives the shallowest of those it matches. Observers run
+// on the writing goroutine after the lock is released, so they can read and
+// write the map.
+type ObservableMap struct {
+	m    *SyncMap
+	mu   sync.RWMutex
+	subs map[int]*subscription
+	next int
+}
+
+// NewObservableMap creates an ObservableMap, optionally taking ownership of an
+// initial Map.
+func NewObservableMap(initial ...Map) *ObservableMap {
+	return &ObservableMap{m: NewSyncMap(initial...), subs: map[int]*subscription{}}
+}
+
+// Subscribe registers fn for the paths matching pattern and returns a function
+// removing the subscription.
+func (o *ObservableMap) Subscribe(pattern string, fn Observer) (unsubscribe func(), err error) {
+	p := dao.Pattern(pattern)
+	if pattern == "" || !p.IsValid() {
+		return nil, fmt.Errorf("invalid pattern %q", pattern)
+	}
+	o.mu.Lock()
+	id := o.next
+	o.next++
+	o.subs[id] = &subscription{pattern: p, fn: fn}
+	o.mu.Unlock()
+	return func() {
+		o.mu.Lock()
+		delete(o.subs, id)
+		o.mu.Unlock()
+	}, nil
+}
+
+func (o *ObservableMap) Get(p string, defaultValue ...any) Result {
+	return o.m.Get(p, defaultValue...)
+}
+
+func (o *ObservableMap) Has(p string) bool {
+	return o.m.Has(p)
+}
+
+func (o *ObservableMap) Snapshot() Map {
+	return o.m.Snapshot()
+}
+
+func (o *ObservableMap) Set(p string, value any) error {
+	s := o.m
+	s.mu.Lock()
+	old := s.m.get(p)
+	err := s.m.set(p, cloneValue(value))
+	if err == nil {
+		s.version++
+	}
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	var changes []Change
+	collectChanges("set", p, old.Value(), value, old.Exists(), true, true, &changes)
+	o.notify(changes)
+	return nil
+}
+
+func (o *ObservableMap) Del(p string) Result {
+	s := o.m
+	s.mu.Lock()
+	old, ok := s.m.del(p)
+	if ok {
+		s.version++
+	}
+	s.mu.Unlock()
+	if ok {
+		var changes []Change
+		collectChanges("del", p, old.Value(), nil, true, false, true, &changes)
+		o.notify(changes)
+	}
+	return old
+}
+
+// Merge deep merges src, see Map.MergeDeep, and reports every changed value.
+func (o *ObservableMap) Merge(src Map, opts ...MergeOptions) {
+	s := o.m
+	s.mu.Lock()
+	before := make(Map, len(src))
+	for k := range src {
+		if v, ok := s.m[k]; ok {
+			before[k] = cloneValue(v)
+		}
+	}
+	s.m.MergeDeep(src, opts...)
+	s.version++
+	var changes []Change
+	keys := MapKeys(src)
+	sort.Strings(keys)
+	for _, k := range keys {
+		old, existed := before[k]
+		v, exists := s.m[k]
+		collectChanges("merge", EscapePathKey(k), old, v, existed, exists, false, &changes)
+	}
+	s.mu.Unlock()
+	o.notify(changes)
+}
+
+// collectChanges appends the change at path when written is set or the value
+// differs, followed by the changes of the values below it.
+func collectChanges(op, path string, old, new any, existed, exists, written bool, out *[]Change) {
+	oldObj, oldIsObj := asObject(old)
+	newObj, newIsObj := asObject(new)
+	if existed == exists && jsonEqual(old, new) {
+		return
+	}
+	if written || !((oldIsObj || !existed) && (newIsObj || !exists)) {
+		*out = append(*out, Change{Op: op, Path: path, Old: old, New: new, Existed: existed, Exists: exists})
+	}
+	if !oldIsObj && !newIsObj {
+		return
+	}
+	keys := map[string]bool{}
+	for k := range oldObj {
+		keys[k] = true
+	}
+	for k := range newObj {
+		keys[k] = true
+	}
+	sorted := MapKeys(keys)
+	sort.Strings(sorted)
+	for _, k := range sorted {
+		ov, oe := oldObj[k]
+		nv, ne := newObj[k]
+		collectChanges(op, path+"."+EscapePathKey(k), ov, nv, oe, ne, false, out)
+	}
+}
+
+func (o *ObservableMap) notify(changes []Change) {
+	if len(changes) == 0 {
+		return
+	}
+	o.mu.RLock()
+	ids := MapKeys(o.subs)
+	sort.Ints(ids)
+	subs := make([]*subscription, 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, o.subs[id])
+	}
+	o.mu.RUnlock()
+
+	for _, sub := range subs {
+		var delivered []string
+		for _, c := range changes {
+			if under(c.Path, delivered) {
+				continue
+			}
+			tags, ok := sub.pattern.Values(c.Path)
+			if !ok {
+				continue
+			}
+			delivered = append(delivered, c.Path)
+			c.Tags = tags
+			sub.fn(c)
+		}
+	}
+}
+
+// under reports whether path is one of parents or below one of them.
+func under(path string, parents []string) bool {
+	for _, p := range parents {
+		if path == p || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// EscapePathKey escapes the characters of k that have a meaning in Get paths
+// (".*?|#@" and backslash), so that k can be used as a path segment.
+func EscapePathKey(k string) string {
+	var b strings.Builder
+	for i := 0; i < len(k); i++ {
+		switch k[i] {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(k[i])
+	}
+	return b.String()
+}
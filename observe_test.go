@@ -0,0 +1,48 @@
+package godao
+
+import (
+	"testing"
+)
+
+func TestObservableMap(t *testing.T) {
+	o := NewObservableMap(Map{"users": Map{"1": Map{"email": "a@x", "name": "a"}}})
+
+	var emails []Change
+	var features []string
+	o.Subscribe("users.$id.email", func(c Change) { emails = append(emails, c) })
+	unsub, _ := o.Subscribe("features.>", func(c Change) {
+		features = append(features, c.Path)
+		o.Get(c.Path)
+	})
+
+	o.Set("users.1.email", "b@x")
+	o.Set("users.1.name", "b")
+	o.Set("users.2", Map{"email": "c@x"})
+	o.Del("users.1")
+
+	if len(emails) != 3 {
+		t.Fatalf("email changes = %+v", emails)
+	}
+	if c := emails[0]; c.Old != "a@x" || c.New != "b@x" || c.Tags["id"] != "1" {
+		t.Errorf("first change = %+v", c)
+	}
+	if c := emails[1]; c.Existed || c.New != "c@x" || c.Tags["id"] != "2" {
+		t.Errorf("second change = %+v", c)
+	}
+	if c := emails[2]; c.Exists || c.Old != "b@x" {
+		t.Errorf("delete change = %+v", c)
+	}
+
+	o.Merge(Map{"features": Map{"beta": true, "dark": Map{"on": true}}})
+	if len(features) != 2 || features[0] != "features.beta" || features[1] != "features.dark.on" {
+		t.Errorf("feature changes = %v", features)
+	}
+	unsub()
+	o.Set("features.beta", false)
+	if len(features) != 2 {
+		t.Error("notified after unsubscribe")
+	}
+	if _, err := o.Subscribe("a.>.b", nil); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
@@ -0,0 +1,399 @@
+package godao
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hyprstereo/go-dao/encoding/hjson"
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+// OrderedMap is an object keeping its keys in insertion order. JSON, HJSON
+// and msgpack encode it in that order, and decoding keeps the order of the
+// document, nested objects being decoded as *OrderedMap. The zero value is
+// ready to use. Like Go maps, it is not safe for concurrent writes.
+type OrderedMap struct {
+	keys []string
+	vals map[string]any
+}
+
+// NewOrderedMap creates an OrderedMap, copying the keys of an initial Map in
+// sorted order.
+func NewOrderedMap(initial ...Map) *OrderedMap {
+	o := &OrderedMap{vals: map[string]any{}}
+	if len(initial) > 0 {
+		mu.RLock()
+		keys := make([]string, 0, len(initial[0]))
+		for k := range initial[0] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			o.Set(k, initial[0][k])
+		}
+		mu.RUnlock()
+	}
+	return o
+}
+
+func (o *OrderedMap) Len() int {
+	return len(o.keys)
+}
+
+// Keys returns a copy of the keys, in order.
+func (o *OrderedMap) Keys() []string {
+	return append([]string{}, o.keys...)
+}
+
+func (o *OrderedMap) Has(key string) bool {
+	_, ok := o.vals[key]
+	return ok
+}
+
+// Get returns the value of key, which is a plain key and not a path.
+func (o *OrderedMap) Get(key string) (v any, ok bool) {
+	v, ok = o.vals[key]
+	return
+}
+
+// Set stores value at key. A new key is appended, an existing one keeps its
+// position.
+func (o *OrderedMap) Set(key string, value any) {
+	if o.vals == nil {
+		o.vals = map[string]any{}
+	}
+	if _, ok := o.vals[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.vals[key] = value
+}
+
+// Del removes key and returns its value.
+func (o *OrderedMap) Del(key string) (v any, ok bool) {
+	if v, ok = o.vals[key]; !ok {
+		return
+	}
+	x := o.Index(key)
+	delete(o.vals, key)
+	o.keys = append(o.keys[:x], o.keys[x+1:]...)
+	return
+}
+
+// Index returns the position of key, or -1.
+func (o *OrderedMap) Index(key string) int {
+	if _, ok := o.vals[key]; ok {
+		for x, k := range o.keys {
+			if k == key {
+				return x
+			}
+		}
+	}
+	return -1
+}
+
+// GetByIndex returns the entry at position i, or "" and nil when out of
+// range.
+func (o *OrderedMap) GetByIndex(i int) (k string, v any) {
+	if i < 0 || i >= len(o.keys) {
+		return "", nil
+	}
+	k = o.keys[i]
+	return k, o.vals[k]
+}
+
+// Insert stores value at key and moves key to position i. Like Move, negative
+// positions count from the end, -1 being the last one. Out of range
+// positions fail without changing o.
+func (o *OrderedMap) Insert(i int, key string, value any) (err error) {
+	n := len(o.keys)
+	if !o.Has(key) {
+		n++
+	}
+	if i < 0 {
+		i += n
+	}
+	if i < 0 || i >= n {
+		return fmt.Errorf("index %d out of range [0:%d]", i, n)
+	}
+	o.Set(key, value)
+	return o.Move(key, i)
+}
+
+// Move moves key to position i, shifting the keys in between. Negative
+// positions count from the end, -1 being the last one.
+func (o *OrderedMap) Move(key string, i int) (err error) {
+	from := o.Index(key)
+	if from < 0 {
+		return fmt.Errorf("%s: %w", key, ErrNotFound)
+	}
+	if i < 0 {
+		i += len(o.keys)
+	}
+	if i < 0 || i >= len(o.keys) {
+		return fmt.Errorf("index %d out of range [0:%d]", i, len(o.keys))
+	}
+	if from < i {
+		copy(o.keys[from:i], o.keys[from+1:i+1])
+	} else {
+		copy(o.keys[i+1:from+1], o.keys[i:from])
+	}
+	o.keys[i] = key
+	return
+}
+
+// ForEach calls cb with each entry, in order.
+func (o *OrderedMap) ForEach(cb func(int, string, any)) {
+	for x, k := range o.keys {
+		cb(x, k, o.vals[k])
+	}
+}
+
+// Clone returns a deep copy of o.
+func (o *OrderedMap) Clone() *OrderedMap {
+	c := &OrderedMap{keys: append([]string{}, o.keys...), vals: make(map[string]any, len(o.vals))}
+	for k, v := range o.vals {
+		c.vals[k] = cloneOrdered(v)
+	}
+	return c
+}
+
+func cloneOrdered(v any) any {
+	switch val := v.(type) {
+	case *OrderedMap:
+		return val.Clone()
+	case []any:
+		arr := make([]any, len(val))
+		for x, e := range val {
+			arr[x] = cloneOrdered(e)
+		}
+		return arr
+	}
+	return cloneValue(v)
+}
+
+// Map converts o into a Map, nested *OrderedMap included.
+func (o *OrderedMap) Map() Map {
+	m := make(Map, len(o.keys))
+	for k, v := range o.vals {
+		m[k] = unorder(v)
+	}
+	return m
+}
+
+func unorder(v any) any {
+	switch val := v.(type) {
+	case *OrderedMap:
+		return val.Map()
+	case []any:
+		arr := make([]any, len(val))
+		for x, e := range val {
+			arr[x] = unorder(e)
+		}
+		return arr
+	}
+	return v
+}
+
+// OrderedKeys returns a copy of the keys, in order, see Keys.
+func (o *OrderedMap) OrderedKeys() []string {
+	return o.Keys()
+}
+
+func (o *OrderedMap) OrderedValue(key string) any {
+	return o.vals[key]
+}
+
+func (o *OrderedMap) Bytes(pretty ...bool) json.RawValue {
+	return json.Encode(o, pretty...)
+}
+
+func (o *OrderedMap) JSON(pretty ...bool) string {
+	return string(o.Bytes(pretty...))
+}
+
+func (o *OrderedMap) HJSON() string {
+	data, _ := hjson.Marshal(o)
+	return string(data)
+}
+
+func (o *OrderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.WriteToken(json.Delim('{')); err != nil {
+		return nil, err
+	}
+	for _, k := range o.keys {
+		if err := enc.WriteToken(k); err != nil {
+			return nil, err
+		}
+		if err := enc.Encode(o.vals[k]); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.WriteToken(json.Delim('}')); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte{'\n'}), nil
+}
+
+func (o *OrderedMap) UnmarshalJSON(data []byte) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	tok, err := d.Token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("cannot decode %v into an OrderedMap", tok)
+	}
+	*o = OrderedMap{}
+	return o.decodeJSON(d)
+}
+
+// decodeJSON reads the entries of an object, up to its closing brace.
+func (o *OrderedMap) decodeJSON(d *json.Decoder) error {
+	o.vals = map[string]any{}
+	for d.More() {
+		key, err := d.Token()
+		if err != nil {
+			return err
+		}
+		v, err := decodeOrderedJSON(d)
+		if err != nil {
+			return err
+		}
+		o.Set(key.(string), v)
+	}
+	_, err := d.Token()
+	return err
+}
+
+func decodeOrderedJSON(d *json.Decoder) (v any, err error) {
+	tok, err := d.Token()
+	if err != nil {
+		return
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := &OrderedMap{}
+		return obj, obj.decodeJSON(d)
+	case json.Delim('['):
+		arr := []any{}
+		for d.More() {
+			if v, err = decodeOrderedJSON(d); err != nil {
+				return
+			}
+			arr = append(arr, v)
+		}
+		_, err = d.Token()
+		return arr, err
+	}
+	return tok, nil
+}
+
+// FromHJSON replaces the content of o with the HJSON (or JSON) object in data.
+func (o *OrderedMap) FromHJSON(data []byte) error {
+	v, err := hjson.UnmarshalOrdered(data)
+	if err != nil {
+		return err
+	}
+	obj, ok := v.(*hjson.Object)
+	if !ok {
+		return fmt.Errorf("cannot decode %T into an OrderedMap", v)
+	}
+	*o = *fromHJSON(obj).(*OrderedMap)
+	return nil
+}
+
+func fromHJSON(v any) any {
+	switch val := v.(type) {
+	case *hjson.Object:
+		o := &OrderedMap{keys: val.Keys, vals: val.Values}
+		for k, e := range o.vals {
+			o.vals[k] = fromHJSON(e)
+		}
+		return o
+	case []any:
+		for x, e := range val {
+			val[x] = fromHJSON(e)
+		}
+	}
+	return v
+}
+
+func (o *OrderedMap) EncodeMsgpack(enc *msgpack.Encoder) (err error) {
+	if err = enc.EncodeMapLen(len(o.keys)); err != nil {
+		return
+	}
+	for _, k := range o.keys {
+		if err = enc.EncodeString(k); err != nil {
+			return
+		}
+		if err = enc.Encode(o.vals[k]); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (o *OrderedMap) DecodeMsgpack(dec *msgpack.Decoder) (err error) {
+	n, err := dec.DecodeMapLen()
+	if err != nil {
+		return
+	}
+	if n < 0 {
+		return errors.New("cannot decode nil into an OrderedMap")
+	}
+	*o = OrderedMap{vals: make(map[string]any, sizeHint(n))}
+	for x := 0; x < n; x++ {
+		var k string
+		var v any
+		if k, err = dec.DecodeString(); err != nil {
+			return
+		}
+		if v, err = decodeOrderedMsgpack(dec); err != nil {
+			return
+		}
+		o.Set(k, v)
+	}
+	return
+}
+
+func decodeOrderedMsgpack(dec *msgpack.Decoder) (v any, err error) {
+	c, err := dec.PeekCode()
+	if err != nil {
+		return
+	}
+	switch {
+	case msgpcode.IsFixedMap(c) || c == msgpcode.Map16 || c == msgpcode.Map32:
+		obj := &OrderedMap{}
+		return obj, obj.DecodeMsgpack(dec)
+	case msgpcode.IsFixedArray(c) || c == msgpcode.Array16 || c == msgpcode.Array32:
+		n, err := dec.DecodeArrayLen()
+		if err != nil {
+			return nil, err
+		}
+		arr := make([]any, 0, sizeHint(n))
+		for x := 0; x < n; x++ {
+			e, err := decodeOrderedMsgpack(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, e)
+		}
+		return arr, nil
+	}
+	return dec.DecodeInterface()
+}
+
+// sizeHint bounds the preallocation for a length read from the input, which
+// may be far larger than the data that follows.
+func sizeHint(n int) int {
+	if n > 1024 {
+		return 1024
+	}
+	return n
+}
@@ -0,0 +1,112 @@
+package godao
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
+
+func TestOrderedMap(t *testing.T) {
+	var o OrderedMap
+	o.Set("zeta", 1)
+	o.Set("alpha", 2)
+	o.Set("mid", 3)
+	o.Set("zeta", 4)
+	if got := strings.Join(o.Keys(), ","); got != "zeta,alpha,mid" {
+		t.Errorf("Keys = %s", got)
+	}
+	if k, v := o.GetByIndex(0); k != "zeta" || v != 4 {
+		t.Errorf("GetByIndex(0) = %s, %v", k, v)
+	}
+	if err := o.Move("mid", 0); err != nil || strings.Join(o.Keys(), ",") != "mid,zeta,alpha" {
+		t.Errorf("Move = %v, %v", o.Keys(), err)
+	}
+	if err := o.Move("mid", -1); err != nil || strings.Join(o.Keys(), ",") != "zeta,alpha,mid" {
+		t.Errorf("Move(-1) = %v, %v", o.Keys(), err)
+	}
+	if err := o.Insert(1, "beta", nil); err != nil || o.Index("beta") != 1 {
+		t.Errorf("Insert = %v, %v", o.Keys(), err)
+	}
+	if _, ok := o.Del("alpha"); !ok || o.Len() != 3 || o.Index("mid") != 2 {
+		t.Errorf("Del = %v", o.Keys())
+	}
+	if err := o.Move("nope", 0); err == nil {
+		t.Error("Move of a missing key should fail")
+	}
+	if err := o.Insert(9, "x", 1); err == nil || o.Has("x") {
+		t.Error("Insert out of range should fail without changing the map")
+	}
+	if err := o.Insert(3, "zeta", 0); err == nil || o.Index("zeta") != 0 {
+		t.Error("Insert of an existing key past the end should fail")
+	}
+	if err := o.Insert(-1, "omega", 5); err != nil || strings.Join(o.Keys(), ",") != "zeta,beta,mid,omega" {
+		t.Errorf("Insert(-1) = %v, %v", o.Keys(), err)
+	}
+	if err := o.Insert(-2, "zeta", 0); err != nil || strings.Join(o.Keys(), ",") != "beta,mid,zeta,omega" {
+		t.Errorf("Insert(-2) of an existing key = %v, %v", o.Keys(), err)
+	}
+	if err := o.Insert(-6, "x", 1); err == nil || o.Has("x") {
+		t.Error("Insert before the start should fail without changing the map")
+	}
+	o.OrderedKeys()[0] = "changed"
+	if o.Keys()[0] != "beta" {
+		t.Errorf("OrderedKeys exposed the keys: %v", o.Keys())
+	}
+}
+
+func TestOrderedMapEncoding(t *testing.T) {
+	src := `{"z":1,"a":{"y":true,"b":[{"k2":null,"k1":"v"}]},"m":"s"}`
+	var o OrderedMap
+	if err := json.Decode([]byte(src), &o); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.JSON(); got != src {
+		t.Errorf("JSON = %s", got)
+	}
+	if got := string(JsonEncode(Map{"wrap": &o})); got != `{"wrap":`+src+`}` {
+		t.Errorf("nested JSON = %s", got)
+	}
+	if m := o.Map(); m.Get("a.b.0.k1").String() != "v" {
+		t.Errorf("Map = %v", m)
+	}
+
+	h := o.HJSON()
+	if strings.Index(h, "z:") > strings.Index(h, "a:") || strings.Index(h, "k2:") > strings.Index(h, "k1:") {
+		t.Errorf("HJSON order lost:\n%s", h)
+	}
+	var back OrderedMap
+	if err := back.FromHJSON([]byte(h)); err != nil || back.JSON() != src {
+		t.Errorf("FromHJSON = %s, %v", back.JSON(), err)
+	}
+
+	data, err := MsgEncode(&o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mp OrderedMap
+	if err = MsgDecode(data, &mp); err != nil || mp.JSON() != src {
+		t.Errorf("msgpack = %s, %v", mp.JSON(), err)
+	}
+
+	c := o.Clone()
+	inner, _ := c.Get("a")
+	inner.(*OrderedMap).Set("y", false)
+	if v, _ := o.Get("a"); v.(*OrderedMap).vals["y"] != true {
+		t.Error("Clone shares nested objects")
+	}
+	done := make(chan error, 1)
+	go func() { done <- MsgDecode([]byte{0x81, 0xa1, 0x61, 0xdf, 0x0f, 0xff, 0xff, 0xff}, &mp) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("truncated msgpack should fail")
+		}
+	case <-time.After(time.Second):
+		t.Error("huge msgpack lengths should not be preallocated")
+	}
+	if err := back.UnmarshalJSON([]byte(`[1]`)); err == nil {
+		t.Error("decoding an array should fail")
+	}
+}
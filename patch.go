@@ -0,0 +1,448 @@
+package godao
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
+
+// RFC 6902 operation names.
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+var ErrTestFailed = errors.New("test operation failed")
+
+// PatchOp is a single RFC 6902 JSON Patch operation.
+type PatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON writes value for the operations that carry one, even when nil.
+func (op PatchOp) MarshalJSON() ([]byte, error) {
+	m := Map{"op": op.Op, "path": op.Path}
+	switch op.Op {
+	case PatchAdd, PatchReplace, PatchTest:
+		m["value"] = op.Value
+	case PatchMove, PatchCopy:
+		m["from"] = op.From
+	}
+	return json.Marshal(map[string]any(m))
+}
+
+// Patch is an RFC 6902 JSON Patch document.
+type Patch []PatchOp
+
+func (p Patch) Bytes() RawValue {
+	return json.Encode(p)
+}
+
+// ParsePatch decodes a JSON Patch document.
+func ParsePatch(data []byte) (p Patch, err error) {
+	err = json.Decode(data, &p)
+	return
+}
+
+// DiffOptions controls the operations emitted by Diff.
+type DiffOptions struct {
+	// Moves turns an object member removed in one place and added with an
+	// equal value in another into a single move.
+	Moves bool
+	// Tests emits a test of the old value before every replace and remove.
+	Tests bool
+}
+
+// Diff returns the patch that turns m into target.
+func (m Map) Diff(target Map, opts ...DiffOptions) (p Patch) {
+	var opt DiffOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	d := &differ{opt: opt}
+	d.diff("", m, target, false)
+	if opt.Moves {
+		d.pairMoves()
+	}
+	p = make(Patch, 0, len(d.ops))
+	for _, op := range d.ops {
+		if !op.dropped {
+			p = append(p, op.PatchOp)
+		}
+	}
+	return
+}
+
+type diffOp struct {
+	PatchOp
+	old     any
+	inArray bool
+	dropped bool
+}
+
+type differ struct {
+	opt DiffOptions
+	ops []*diffOp
+}
+
+func (d *differ) emit(op PatchOp, old any, inArray bool) {
+	if d.opt.Tests && (op.Op == PatchReplace || op.Op == PatchRemove) {
+		d.ops = append(d.ops, &diffOp{PatchOp: PatchOp{Op: PatchTest, Path: op.Path, Value: old}, inArray: inArray})
+	}
+	d.ops = append(d.ops, &diffOp{PatchOp: op, old: old, inArray: inArray})
+}
+
+func (d *differ) diff(path string, a, b any, inArray bool) {
+	if ao, ok := asObject(a); ok {
+		if bo, ok := asObject(b); ok {
+			keys := MapKeys(ao)
+			sort.Strings(keys)
+			for _, k := range keys {
+				if bv, ok := bo[k]; ok {
+					d.diff(path+"/"+EscapePointer(k), ao[k], bv, inArray)
+				} else {
+					d.emit(PatchOp{Op: PatchRemove, Path: path + "/" + EscapePointer(k)}, ao[k], inArray)
+				}
+			}
+			keys = MapKeys(bo)
+			sort.Strings(keys)
+			for _, k := range keys {
+				if _, ok := ao[k]; !ok {
+					d.emit(PatchOp{Op: PatchAdd, Path: path + "/" + EscapePointer(k), Value: bo[k]}, nil, inArray)
+				}
+			}
+			return
+		}
+	}
+	if aa, ok := asArray(a); ok {
+		if ba, ok := asArray(b); ok {
+			n := len(aa)
+			if len(ba) < n {
+				n = len(ba)
+			}
+			for i := 0; i < n; i++ {
+				d.diff(path+"/"+strconv.Itoa(i), aa[i], ba[i], true)
+			}
+			for i := len(aa) - 1; i >= n; i-- {
+				d.emit(PatchOp{Op: PatchRemove, Path: path + "/" + strconv.Itoa(i)}, aa[i], true)
+			}
+			for i := n; i < len(ba); i++ {
+				d.emit(PatchOp{Op: PatchAdd, Path: path + "/" + strconv.Itoa(i), Value: ba[i]}, nil, true)
+			}
+			return
+		}
+	}
+	if !jsonEqual(a, b) {
+		d.emit(PatchOp{Op: PatchReplace, Path: path, Value: b}, a, inArray)
+	}
+}
+
+// pairMoves replaces a remove and an add of an equal value with a move,
+// placed where the add was. Array members are left alone, as their indexes
+// shift with every operation.
+func (d *differ) pairMoves() {
+	for _, add := range d.ops {
+		if add.Op != PatchAdd || add.inArray {
+			continue
+		}
+		for x, rm := range d.ops {
+			if rm.Op != PatchRemove || rm.inArray || rm.dropped || !jsonEqual(rm.old, add.Value) {
+				continue
+			}
+			rm.dropped = true
+			if x > 0 && d.ops[x-1].Op == PatchTest && d.ops[x-1].Path == rm.Path {
+				d.ops[x-1].dropped = true
+			}
+			add.PatchOp = PatchOp{Op: PatchMove, From: rm.Path, Path: add.Path}
+			break
+		}
+	}
+}
+
+// jsonEqual compares two values the way their JSON forms compare, so that
+// int(1) equals float64(1).
+func jsonEqual(a, b any) bool {
+	if ao, ok := asObject(a); ok {
+		bo, ok := asObject(b)
+		if !ok || len(ao) != len(bo) {
+			return false
+		}
+		for k, v := range ao {
+			bv, ok := bo[k]
+			if !ok || !jsonEqual(v, bv) {
+				return false
+			}
+		}
+		return true
+	}
+	if aa, ok := asArray(a); ok {
+		ba, ok := asArray(b)
+		if !ok || len(aa) != len(ba) {
+			return false
+		}
+		for i := range aa {
+			if !jsonEqual(aa[i], ba[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	if isNumber(a) && isNumber(b) {
+		af, _ := toFloat64(a, CoerceStrict)
+		bf, _ := toFloat64(b, CoerceStrict)
+		return af == bf
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func isNumber(v any) bool {
+	switch v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return true
+	}
+	return false
+}
+
+// EscapePointer escapes a key for use as a JSON Pointer token.
+func EscapePointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// ParsePointer splits an RFC 6901 JSON Pointer into unescaped tokens.
+func ParsePointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid pointer %q", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for x, t := range tokens {
+		tokens[x] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// ApplyPatch applies p to m atomically: either every operation succeeds or
+// m is left untouched.
+func (m Map) ApplyPatch(p Patch) (err error) {
+	mu.Lock()
+	defer mu.Unlock()
+	var doc any = m.Clone()
+	for x, op := range p {
+		if doc, err = applyOp(doc, op); err != nil {
+			return fmt.Errorf("patch op %d (%s %s): %w", x, op.Op, op.Path, err)
+		}
+	}
+	obj, ok := asObject(doc)
+	if !ok {
+		return fmt.Errorf("patch result is not an object")
+	}
+	for k := range m {
+		delete(m, k)
+	}
+	for k, v := range obj {
+		m[k] = v
+	}
+	return
+}
+
+func applyOp(doc any, op PatchOp) (any, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return doc, err
+	}
+	switch op.Op {
+	case PatchAdd:
+		return pointerAdd(doc, path, cloneValue(op.Value))
+	case PatchRemove:
+		doc, _, err = pointerRemove(doc, path)
+		return doc, err
+	case PatchReplace:
+		if _, err = pointerGet(doc, path); err != nil {
+			return doc, err
+		}
+		return pointerReplace(doc, path, cloneValue(op.Value))
+	case PatchMove, PatchCopy:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return doc, err
+		}
+		var v any
+		if op.Op == PatchMove {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return doc, fmt.Errorf("cannot move %s into itself", op.From)
+			}
+			if doc, v, err = pointerRemove(doc, from); err != nil {
+				return doc, err
+			}
+		} else {
+			if v, err = pointerGet(doc, from); err != nil {
+				return doc, err
+			}
+			v = cloneValue(v)
+		}
+		return pointerAdd(doc, path, v)
+	case PatchTest:
+		v, err := pointerGet(doc, path)
+		if err != nil {
+			return doc, err
+		}
+		if !jsonEqual(v, op.Value) {
+			return doc, ErrTestFailed
+		}
+		return doc, nil
+	}
+	return doc, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// pointerParent walks to the parent of path and hands it to fn together with
+// the last token. fn returns the container that replaces the parent.
+func pointerParent(node any, path []string, fn func(parent any, key string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		return fn(node, path[0])
+	}
+	if obj, ok := asObject(node); ok {
+		child, ok := obj[path[0]]
+		if !ok {
+			return node, fmt.Errorf("%w: %s", ErrNotFound, path[0])
+		}
+		nchild, err := pointerParent(child, path[1:], fn)
+		if err == nil {
+			obj[path[0]] = nchild
+		}
+		return node, err
+	}
+	if arr, ok := node.([]any); ok {
+		i, ok := arrayIndex(path[0])
+		if !ok || i >= len(arr) {
+			return node, fmt.Errorf("%w: index %s", ErrNotFound, path[0])
+		}
+		nchild, err := pointerParent(arr[i], path[1:], fn)
+		if err == nil {
+			arr[i] = nchild
+		}
+		return node, err
+	}
+	if node != nil {
+		// typed values are walked in their JSON shape
+		g := toGeneric(node)
+		if _, ok := asObject(g); ok {
+			return pointerParent(g, path, fn)
+		}
+		if _, ok := g.([]any); ok {
+			return pointerParent(g, path, fn)
+		}
+	}
+	return node, fmt.Errorf("%w: %s", ErrNotFound, path[0])
+}
+
+func pointerGet(doc any, path []string) (v any, err error) {
+	if len(path) == 0 {
+		return doc, nil
+	}
+	_, err = pointerParent(doc, path, func(parent any, key string) (any, error) {
+		if obj, ok := asObject(parent); ok {
+			var found bool
+			if v, found = obj[key]; !found {
+				return parent, fmt.Errorf("%w: %s", ErrNotFound, key)
+			}
+			return parent, nil
+		}
+		if arr, ok := asArray(parent); ok {
+			i, ok := arrayIndex(key)
+			if !ok || i >= len(arr) {
+				return parent, fmt.Errorf("%w: index %s", ErrNotFound, key)
+			}
+			v = arr[i]
+			return parent, nil
+		}
+		return parent, fmt.Errorf("%w: %s", ErrNotFound, key)
+	})
+	return
+}
+
+func pointerAdd(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerParent(doc, path, func(parent any, key string) (any, error) {
+		if obj, ok := asObject(parent); ok {
+			obj[key] = value
+			return parent, nil
+		}
+		arr, ok := parent.([]any)
+		if !ok {
+			if arr, ok = asArray(parent); !ok {
+				return parent, fmt.Errorf("cannot add %s to a non-container", key)
+			}
+		}
+		if key == "-" {
+			return append(arr, value), nil
+		}
+		i, ok := arrayIndex(key)
+		if !ok || i > len(arr) {
+			return parent, fmt.Errorf("%w: index %s", ErrNotFound, key)
+		}
+		arr = append(arr, nil)
+		copy(arr[i+1:], arr[i:])
+		arr[i] = value
+		return arr, nil
+	})
+}
+
+func pointerReplace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return pointerParent(doc, path, func(parent any, key string) (any, error) {
+		if obj, ok := asObject(parent); ok {
+			obj[key] = value
+			return parent, nil
+		}
+		arr, _ := asArray(parent)
+		i, _ := arrayIndex(key)
+		arr[i] = value
+		return arr, nil
+	})
+}
+
+func pointerRemove(doc any, path []string) (res any, old any, err error) {
+	if len(path) == 0 {
+		return nil, doc, fmt.Errorf("cannot remove the document root")
+	}
+	res, err = pointerParent(doc, path, func(parent any, key string) (any, error) {
+		if obj, ok := asObject(parent); ok {
+			var found bool
+			if old, found = obj[key]; !found {
+				return parent, fmt.Errorf("%w: %s", ErrNotFound, key)
+			}
+			delete(obj, key)
+			return parent, nil
+		}
+		if arr, ok := asArray(parent); ok {
+			i, ok := arrayIndex(key)
+			if !ok || i >= len(arr) {
+				return parent, fmt.Errorf("%w: index %s", ErrNotFound, key)
+			}
+			old = arr[i]
+			return append(arr[:i:i], arr[i+1:]...), nil
+		}
+		return parent, fmt.Errorf("%w: %s", ErrNotFound, key)
+	})
+	return
+}
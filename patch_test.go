@@ -0,0 +1,85 @@
+package godao
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDiffApplyPatch(t *testing.T) {
+	src := Map{
+		"name": "svc",
+		"port": 80,
+		"tags": []any{"a", "b", "c"},
+		"db":   Map{"host": "localhost", "user": "root"},
+		"old":  Map{"keep": true},
+	}
+	dst := Map{
+		"name": "svc",
+		"port": 8080,
+		"tags": []any{"a", "x"},
+		"db":   Map{"host": "db.internal", "user": "root", "pool": 4},
+		"new":  Map{"keep": true},
+	}
+
+	patch := src.Diff(dst, DiffOptions{Moves: true, Tests: true})
+	moves := 0
+	for _, op := range patch {
+		if op.Op == PatchMove {
+			moves++
+			if op.From != "/old" || op.Path != "/new" {
+				t.Errorf("unexpected move %+v", op)
+			}
+		}
+	}
+	if moves != 1 {
+		t.Errorf("moves = %d, patch = %s", moves, patch.Bytes())
+	}
+
+	parsed, err := ParsePatch(patch.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := src.Clone()
+	if err := got.ApplyPatch(parsed); err != nil {
+		t.Fatal(err)
+	}
+	if rest := got.Diff(dst); len(rest) != 0 {
+		t.Errorf("patched map differs: %s", rest.Bytes())
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	m := Map{"a": 1, "list": []any{1, 2}}
+	err := m.ApplyPatch(Patch{
+		{Op: PatchAdd, Path: "/list/0", Value: 0},
+		{Op: PatchCopy, From: "/a", Path: "/b"},
+		{Op: PatchTest, Path: "/a", Value: 2},
+	})
+	if !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("err = %v", err)
+	}
+	if m.Has("b") || len(m["list"].([]any)) != 2 {
+		t.Errorf("failed patch modified map: %v", m)
+	}
+	if len(m.Diffs(Map{"a": 1})) != 1 {
+		t.Errorf("Diffs = %v", m.Diffs(Map{"a": 1}))
+	}
+}
+
+func TestApplyPatchTypedContainers(t *testing.T) {
+	m := Map{"items": []Map{{"a": 1}}, "tags": map[string][]string{"k": {"x"}}}
+	err := m.ApplyPatch(Patch{
+		{Op: PatchReplace, Path: "/items/0/a", Value: 2},
+		{Op: PatchReplace, Path: "/tags/k/0", Value: "y"},
+		{Op: PatchTest, Path: "/items/0/a", Value: 3},
+	})
+	if !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("err = %v", err)
+	}
+	if a := m["items"].([]Map)[0]["a"]; a != 1 {
+		t.Errorf("failed patch modified items: a = %v", a)
+	}
+	if k := m["tags"].(map[string][]string)["k"][0]; k != "x" {
+		t.Errorf("failed patch modified tags: k = %v", k)
+	}
+}
@@ -0,0 +1,415 @@
+package godao
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/tidwall/gjson"
+)
+
+// pathSegment is a single part of a gjson style path.
+type pathSegment struct {
+	key  string // unescaped key
+	raw  string // segment as written, escapes included
+	wild bool   // contains an unescaped * or ?
+}
+
+// parsePath splits a gjson style path into segments. ok is false when the
+// path uses syntax that is only available on the encoded bytes, such as
+// modifiers, pipes, queries, literals or multipaths.
+func parsePath(p string) (segs []pathSegment, ok bool) {
+	if p == "" {
+		return
+	}
+	switch p[0] {
+	case '@', '!', '[', '{', '.':
+		return
+	}
+	var key []byte
+	start := 0
+	wild := false
+	for i := 0; i < len(p); i++ {
+		switch c := p[i]; c {
+		case '\\':
+			i++
+			if i == len(p) {
+				return nil, false
+			}
+			key = append(key, p[i])
+		case '|':
+			return nil, false
+		case '.':
+			if i == start {
+				return nil, false
+			}
+			segs = append(segs, pathSegment{key: string(key), raw: p[start:i], wild: wild})
+			key, start, wild = key[:0], i+1, false
+		default:
+			if i == start && (c == '@' || c == '!') {
+				return nil, false
+			}
+			if c == '#' && i == start && i+1 < len(p) && p[i+1] == '(' {
+				return nil, false
+			}
+			if c == '*' || c == '?' {
+				wild = true
+			}
+			key = append(key, c)
+		}
+	}
+	if start == len(p) {
+		return nil, false
+	}
+	segs = append(segs, pathSegment{key: string(key), raw: p[start:], wild: wild})
+	ok = true
+	return
+}
+
+// joinSegments rebuilds the path text of segs.
+func joinSegments(segs []pathSegment) string {
+	raws := make([]string, len(segs))
+	for x, s := range segs {
+		raws[x] = s.raw
+	}
+	return strings.Join(raws, ".")
+}
+
+// settable reports whether segs can be written without the bytes fallback.
+func settable(segs []pathSegment) bool {
+	for _, s := range segs {
+		if s.wild || s.key == "#" {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses a gjson array index, digits only.
+func arrayIndex(key string) (int, bool) {
+	if key == "" {
+		return 0, false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(key)
+	return n, err == nil
+}
+
+// asObject returns v as a plain map when it is a Map or map[string]any.
+func asObject(v any) (map[string]any, bool) {
+	switch val := v.(type) {
+	case Map:
+		return val, val != nil
+	case map[string]any:
+		return val, val != nil
+	}
+	return nil, false
+}
+
+// asArray returns v as a []any. Typed slices are copied, so the result is
+// only safe for reading unless v itself was a []any.
+func asArray(v any) ([]any, bool) {
+	switch val := v.(type) {
+	case []any:
+		return val, true
+	case []Map:
+		arr := make([]any, len(val))
+		for x, e := range val {
+			arr[x] = e
+		}
+		return arr, true
+	case nil:
+		return nil, false
+	}
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8) || rv.Kind() == reflect.Array {
+		arr := make([]any, rv.Len())
+		for x := range arr {
+			arr[x] = rv.Index(x).Interface()
+		}
+		return arr, true
+	}
+	return nil, false
+}
+
+// toGeneric converts a value that is neither an object nor an array known to
+// the walker into its JSON shape, so that a path can continue through it.
+func toGeneric(v any) any {
+	if arr, ok := asArray(v); ok {
+		return arr
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Map && rv.Type().Key().Kind() == reflect.String {
+		obj := make(Map, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			obj[iter.Key().String()] = iter.Value().Interface()
+		}
+		return obj
+	}
+	var out any
+	if err := json.Decode(json.Encode(v), &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// wildKey returns the first key of obj, in sorted order, matching seg.
+func wildKey(obj map[string]any, seg pathSegment) (string, bool) {
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if Match(k, seg.raw) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// getPath walks v following segs and returns the value found there.
+func getPath(v any, segs []pathSegment) (any, bool) {
+	for x, seg := range segs {
+		if obj, ok := asObject(v); ok {
+			key := seg.key
+			if seg.wild {
+				if key, ok = wildKey(obj, seg); !ok {
+					return nil, false
+				}
+			}
+			if v, ok = obj[key]; !ok {
+				return nil, false
+			}
+			continue
+		}
+		if arr, ok := asArray(v); ok {
+			if seg.key == "#" && !seg.wild {
+				rest := segs[x+1:]
+				if len(rest) == 0 {
+					return len(arr), true
+				}
+				all := make([]any, 0, len(arr))
+				for _, e := range arr {
+					if ev, ok := getPath(e, rest); ok {
+						all = append(all, ev)
+					}
+				}
+				return all, true
+			}
+			i, ok := arrayIndex(seg.key)
+			if !ok || i >= len(arr) {
+				return nil, false
+			}
+			v = arr[i]
+			continue
+		}
+		if v == nil {
+			return nil, false
+		}
+		// structs and other values are resolved on their encoded form
+		res := gjson.GetBytes(json.Encode(v), joinSegments(segs[x:]))
+		return res.Value(), res.Exists()
+	}
+	return v, true
+}
+
+// setPath stores value at segs inside v and returns the container that must
+// replace v, since arrays may grow and missing parents are created.
+func setPath(v any, segs []pathSegment, value any) (any, error) {
+	if len(segs) == 0 {
+		return value, nil
+	}
+	seg, rest := segs[0], segs[1:]
+	if v != nil {
+		if _, ok := asObject(v); !ok {
+			if _, ok := v.([]any); !ok {
+				v = toGeneric(v)
+			}
+		}
+	}
+	if obj, ok := asObject(v); ok {
+		child, err := setPath(obj[seg.key], rest, value)
+		if err != nil {
+			return v, err
+		}
+		obj[seg.key] = child
+		return v, nil
+	}
+	arr, isArr := v.([]any)
+	if !isArr {
+		if _, numeric := arrayIndex(seg.key); v != nil || !(numeric || seg.key == "-1") {
+			obj := Map{}
+			child, err := setPath(nil, rest, value)
+			if err != nil {
+				return v, err
+			}
+			obj[seg.key] = child
+			return obj, nil
+		}
+	}
+	if seg.key == "-1" {
+		child, err := setPath(nil, rest, value)
+		if err != nil {
+			return v, err
+		}
+		return append(arr, child), nil
+	}
+	i, ok := arrayIndex(seg.key)
+	if !ok {
+		return v, fmt.Errorf("cannot set array element for non-numeric key '%s'", seg.key)
+	}
+	for len(arr) <= i {
+		arr = append(arr, nil)
+	}
+	child, err := setPath(arr[i], rest, value)
+	if err != nil {
+		return v, err
+	}
+	arr[i] = child
+	return arr, nil
+}
+
+// delPath removes segs from v. It returns the container that must replace v,
+// the removed value and whether anything was removed.
+func delPath(v any, segs []pathSegment) (any, any, bool) {
+	seg, rest := segs[0], segs[1:]
+	if obj, ok := asObject(v); ok {
+		child, found := obj[seg.key]
+		if !found {
+			return v, nil, false
+		}
+		if len(rest) == 0 {
+			delete(obj, seg.key)
+			return v, child, true
+		}
+		nchild, old, ok := delPath(child, rest)
+		if ok {
+			obj[seg.key] = nchild
+		}
+		return v, old, ok
+	}
+	arr, ok := v.([]any)
+	if !ok {
+		return v, nil, false
+	}
+	i, ok := arrayIndex(seg.key)
+	if seg.key == "-1" {
+		i, ok = len(arr)-1, true
+	}
+	if !ok || i < 0 || i >= len(arr) {
+		return v, nil, false
+	}
+	if len(rest) == 0 {
+		old := arr[i]
+		return append(arr[:i:i], arr[i+1:]...), old, true
+	}
+	nchild, old, ok := delPath(arr[i], rest)
+	if ok {
+		arr[i] = nchild
+	}
+	return arr, old, ok
+}
+
+// newResult wraps a native value into a Result, keeping the original
+// value for Value() and filling the gjson fields for the other accessors.
+func newResult(v any) (r Result) {
+	switch val := v.(type) {
+	case nil:
+		r.Result = gjson.Result{Type: gjson.Null, Raw: "null"}
+	case bool:
+		if val {
+			r.Result = gjson.Result{Type: gjson.True, Raw: "true"}
+		} else {
+			r.Result = gjson.Result{Type: gjson.False, Raw: "false"}
+		}
+	case int:
+		r.Result = gjson.Result{Type: gjson.Number, Raw: strconv.Itoa(val), Num: float64(val)}
+	case int64:
+		r.Result = gjson.Result{Type: gjson.Number, Raw: strconv.FormatInt(val, 10), Num: float64(val)}
+	case float64:
+		r.Result = gjson.Result{Type: gjson.Number, Raw: strconv.FormatFloat(val, 'f', -1, 64), Num: val}
+	default:
+		r.Result = gjson.ParseBytes(json.Encode(v))
+	}
+	r.val = v
+	r.native = true
+	return
+}
+
+// cloneValue deep copies maps, slices and arrays of any type, other values
+// are shared.
+func cloneValue(v any) any {
+	switch val := v.(type) {
+	case Map:
+		return val.Clone()
+	case map[string]any:
+		return map[string]any(Map(val).Clone())
+	case []any:
+		arr := make([]any, len(val))
+		for x, e := range val {
+			arr[x] = cloneValue(e)
+		}
+		return arr
+	case nil, string, bool, float64, int, int64:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return cloneReflect(rv).Interface()
+	}
+	return v
+}
+
+func cloneReflect(rv reflect.Value) reflect.Value {
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.IsNil() {
+			return rv
+		}
+		return reflect.ValueOf(cloneValue(rv.Interface()))
+	case reflect.Map:
+		if rv.IsNil() {
+			return rv
+		}
+		c := reflect.MakeMapWithSize(rv.Type(), rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			if e := cloneReflect(iter.Value()); e.IsValid() {
+				c.SetMapIndex(iter.Key(), e)
+			} else {
+				c.SetMapIndex(iter.Key(), reflect.Zero(rv.Type().Elem()))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if rv.IsNil() {
+			return rv
+		}
+		c := reflect.MakeSlice(rv.Type(), rv.Len(), rv.Len())
+		cloneElems(c, rv)
+		return c
+	case reflect.Array:
+		c := reflect.New(rv.Type()).Elem()
+		cloneElems(c, rv)
+		return c
+	}
+	return rv
+}
+
+func cloneElems(dst, src reflect.Value) {
+	for x := 0; x < src.Len(); x++ {
+		if e := cloneReflect(src.Index(x)); e.IsValid() {
+			dst.Index(x).Set(e)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package godao
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMapPathGet(t *testing.T) {
+	m := Map{
+		"name": "dao",
+		"size": 42,
+		"user": Map{"first": "jo", "last": "doe"},
+		"tags": []any{"a", "b", "c"},
+		"items": []any{
+			map[string]any{"id": 1},
+			map[string]any{"id": 2},
+			map[string]any{"other": true},
+		},
+		"dot.key": "escaped",
+	}
+
+	tests := []struct {
+		path string
+		want any
+	}{
+		{"name", "dao"},
+		{"size", 42},
+		{"user.last", "doe"},
+		{"tags.1", "b"},
+		{"tags.#", 3},
+		{"items.#.id", []any{1, 2}},
+		{"us*.first", "jo"},
+		{`dot\.key`, "escaped"},
+	}
+	for _, tt := range tests {
+		if got := m.Get(tt.path).Value(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+	if m.Get("user.missing").Exists() {
+		t.Error("missing path exists")
+	}
+	if got := m.Get("tags|@reverse").Value(); !reflect.DeepEqual(got, []any{"c", "b", "a"}) {
+		t.Errorf("modifier fallback = %#v", got)
+	}
+}
+
+func TestMapPathSetDel(t *testing.T) {
+	m := Map{"a": Map{"b": 1}}
+
+	m.Set("a.c", int64(7))
+	m.Set("list.-1", "x")
+	m.Set("list.2", "z")
+	m.Set("grid.1", true)
+
+	if got := m.Get("a.c").Value(); got != int64(7) {
+		t.Errorf("a.c = %#v", got)
+	}
+	if got := m.Get("list").Value(); !reflect.DeepEqual(got, []any{"x", nil, "z"}) {
+		t.Errorf("list = %#v", got)
+	}
+	if got := m.Get("grid").Value(); !reflect.DeepEqual(got, []any{nil, true}) {
+		t.Errorf("grid = %#v", got)
+	}
+
+	if old := m.Del("list.0"); old.Value() != "x" {
+		t.Errorf("Del returned %#v", old.Value())
+	}
+	if got := m.Get("list").Value(); !reflect.DeepEqual(got, []any{nil, "z"}) {
+		t.Errorf("list after Del = %#v", got)
+	}
+	m.Del("a.b")
+	if m.Get("a.b").Exists() {
+		t.Error("a.b still exists")
+	}
+}
@@ -0,0 +1,301 @@
+package godao
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/hjson"
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/hyprstereo/go-dao/encoding/msg"
+	"github.com/spf13/afero"
+)
+
+// ErrLocked is returned when the lock file of a PersistentMap is held by
+// someone else for longer than PersistOptions.LockTimeout.
+var ErrLocked = errors.New("file is locked")
+
+// PersistOptions configures a PersistentMap.
+type PersistOptions struct {
+	// Fs holds the file, defaults to the OS file system.
+	Fs afero.Fs
+	// Format is "json", "hjson" or "msgpack". By default it is chosen by the
+	// extension of the path, ".hjson", ".msgpack" and ".mpk" or JSON.
+	Format string
+	// Pretty indents the JSON output.
+	Pretty bool
+	// Mode is the permission of a new file, defaults to 0644. An existing
+	// file keeps its own.
+	Mode os.FileMode
+	// Autosave saves the map once no change happened for that long, 0
+	// disables it and Save must be called.
+	Autosave time.Duration
+	// OnError receives the errors of autosaves.
+	OnError func(error)
+	// LockTimeout bounds the wait for the lock file, defaults to 5s. A lock
+	// file older than 30s is considered stale and removed.
+	LockTimeout time.Duration
+}
+
+const staleLock = 30 * time.Second
+
+// PersistentMap is a SyncMap bound to a file. The file is written to a
+// temporary file renamed over it, so readers never see a partial write, and
+// a "<path>.lock" file serializes the processes saving or loading it.
+type PersistentMap struct {
+	m     *SyncMap
+	path  string
+	opt   PersistOptions
+	io    sync.Mutex // serializes Save and Reload
+	timer *time.Timer
+	tmu   sync.Mutex
+	saved uint64 // version of the last save or load
+}
+
+// NewPersistentMap binds a map to path, loading the file when it exists.
+func NewPersistentMap(path string, opts ...PersistOptions) (p *PersistentMap, err error) {
+	p = &PersistentMap{m: NewSyncMap(), path: path}
+	if len(opts) > 0 {
+		p.opt = opts[0]
+	}
+	if p.opt.Fs == nil {
+		p.opt.Fs = afero.NewOsFs()
+	}
+	if p.opt.Mode == 0 {
+		p.opt.Mode = 0644
+	}
+	if p.opt.LockTimeout == 0 {
+		p.opt.LockTimeout = 5 * time.Second
+	}
+	if p.opt.Format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".hjson":
+			p.opt.Format = "hjson"
+		case ".msgpack", ".mpk":
+			p.opt.Format = "msgpack"
+		default:
+			p.opt.Format = "json"
+		}
+	}
+	switch p.opt.Format {
+	case "json", "hjson", "msgpack":
+	default:
+		return nil, fmt.Errorf("unknown format %q", p.opt.Format)
+	}
+	if _, err = p.opt.Fs.Stat(path); os.IsNotExist(err) {
+		return p, nil
+	}
+	if err = p.Reload(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Path returns the path of the file.
+func (p *PersistentMap) Path() string {
+	return p.path
+}
+
+func (p *PersistentMap) Get(path string, defaultValue ...any) Result {
+	return p.m.Get(path, defaultValue...)
+}
+
+func (p *PersistentMap) Has(path string) bool {
+	return p.m.Has(path)
+}
+
+// Snapshot returns a deep copy of the current content.
+func (p *PersistentMap) Snapshot() Map {
+	return p.m.Snapshot()
+}
+
+func (p *PersistentMap) Set(path string, value any) (err error) {
+	if err = p.m.Set(path, value); err == nil {
+		p.changed()
+	}
+	return
+}
+
+func (p *PersistentMap) Del(path string) (res Result) {
+	if res = p.m.Del(path); res.Exists() {
+		p.changed()
+	}
+	return
+}
+
+// Update replaces the value at path with the result of fn, see SyncMap.Update.
+func (p *PersistentMap) Update(path string, fn func(old any) any) (value any, err error) {
+	if value, err = p.m.Update(path, fn); err == nil {
+		p.changed()
+	}
+	return
+}
+
+// Merge deep merges src, see Map.MergeDeep.
+func (p *PersistentMap) Merge(src Map, opts ...MergeOptions) {
+	s := p.m
+	s.mu.Lock()
+	s.m.MergeDeep(src, opts...)
+	s.version++
+	s.mu.Unlock()
+	p.changed()
+}
+
+// Dirty reports whether the map changed since it was last saved or loaded.
+func (p *PersistentMap) Dirty() bool {
+	p.io.Lock()
+	defer p.io.Unlock()
+	return p.m.Version() != p.saved
+}
+
+// changed schedules an autosave, postponing the pending one.
+func (p *PersistentMap) changed() {
+	if p.opt.Autosave <= 0 {
+		return
+	}
+	p.tmu.Lock()
+	defer p.tmu.Unlock()
+	if p.timer == nil {
+		p.timer = time.AfterFunc(p.opt.Autosave, p.autosave)
+	} else {
+		p.timer.Reset(p.opt.Autosave)
+	}
+}
+
+func (p *PersistentMap) autosave() {
+	if err := p.Save(); err != nil && p.opt.OnError != nil {
+		p.opt.OnError(err)
+	}
+}
+
+func (p *PersistentMap) stopTimer() {
+	p.tmu.Lock()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.tmu.Unlock()
+}
+
+// Save writes the map to its file.
+func (p *PersistentMap) Save() (err error) {
+	p.io.Lock()
+	defer p.io.Unlock()
+	p.m.mu.RLock()
+	version := p.m.version
+	data, err := p.encode(p.m.m)
+	p.m.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	unlock, err := p.lock()
+	if err != nil {
+		return
+	}
+	defer unlock()
+	if err = json.WriteFileAtomic(p.opt.Fs, p.path, data, p.opt.Mode); err == nil {
+		p.saved = version
+	}
+	return
+}
+
+// Reload replaces the content with the one of the file, picking up external
+// edits. Unsaved changes are discarded, unless the file cannot be read or
+// decoded.
+func (p *PersistentMap) Reload() (err error) {
+	p.io.Lock()
+	defer p.io.Unlock()
+	unlock, err := p.lock()
+	if err != nil {
+		return
+	}
+	data, err := afero.ReadFile(p.opt.Fs, p.path)
+	unlock()
+	if err != nil {
+		return
+	}
+	m, err := p.decode(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", p.path, err)
+	}
+	p.stopTimer()
+	s := p.m
+	s.mu.Lock()
+	s.m = m
+	s.version++
+	p.saved = s.version
+	s.mu.Unlock()
+	return
+}
+
+// Close stops the autosave and saves the pending changes.
+func (p *PersistentMap) Close() error {
+	p.stopTimer()
+	if p.Dirty() {
+		return p.Save()
+	}
+	return nil
+}
+
+func (p *PersistentMap) encode(m Map) (data []byte, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	switch p.opt.Format {
+	case "hjson":
+		return hjson.Marshal(m)
+	case "msgpack":
+		return msg.Encode(m)
+	}
+	if p.opt.Pretty {
+		return json.MarshalIndent(m, "", "  ")
+	}
+	return json.Marshal(m)
+}
+
+func (p *PersistentMap) decode(data []byte) (m Map, err error) {
+	m = Map{}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return
+	}
+	switch p.opt.Format {
+	case "hjson":
+		err = hjson.Unmarshal(data, &m)
+	case "msgpack":
+		err = msg.Decode(data, &m)
+	default:
+		err = json.Decode(data, &m)
+	}
+	if err == nil && m == nil {
+		m = Map{}
+	}
+	return
+}
+
+// lock creates the lock file, waiting for it to be released.
+func (p *PersistentMap) lock() (unlock func(), err error) {
+	name := p.path + ".lock"
+	deadline := time.Now().Add(p.opt.LockTimeout)
+	for {
+		f, err := p.opt.Fs.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.WriteString(strconv.Itoa(os.Getpid()))
+			f.Close()
+			return func() { p.opt.Fs.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if fi, e := p.opt.Fs.Stat(name); e == nil && time.Since(fi.ModTime()) > staleLock {
+			p.opt.Fs.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s: %w", p.path, ErrLocked)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
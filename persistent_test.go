@@ -0,0 +1,97 @@
+package godao
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestPersistentMap(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for _, path := range []string{"/cfg/app.json", "/cfg/app.hjson", "/cfg/app.msgpack"} {
+		p, err := NewPersistentMap(path, PersistOptions{Fs: fs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Set("name", "dao")
+		p.Set("db.port", 5432)
+		if !p.Dirty() {
+			t.Errorf("%s: should be dirty", path)
+		}
+		if err = p.Save(); err != nil {
+			t.Fatal(err)
+		}
+		fi, err := fs.Stat(path)
+		if err != nil || fi.Mode().Perm() != 0644 {
+			t.Errorf("%s: stat = %v, %v", path, fi, err)
+		}
+		q, err := NewPersistentMap(path, PersistOptions{Fs: fs})
+		if err != nil || q.Get("db.port").Int() != 5432 || q.Get("name").String() != "dao" {
+			t.Errorf("%s: reopened = %v, %v", path, q.Snapshot(), err)
+		}
+	}
+	files, _ := afero.ReadDir(fs, "/cfg")
+	if len(files) != 3 {
+		t.Errorf("temporary or lock files left: %d files", len(files))
+	}
+
+	p, _ := NewPersistentMap("/cfg/app.json", PersistOptions{Fs: fs})
+	afero.WriteFile(fs, "/cfg/app.json", []byte(`{"name": "edited"}`), 0644)
+	if err := p.Reload(); err != nil || p.Get("name").String() != "edited" || p.Has("db") {
+		t.Errorf("Reload = %v, %v", p.Snapshot(), err)
+	}
+	afero.WriteFile(fs, "/cfg/app.json", []byte(`{"name": `), 0644)
+	if err := p.Reload(); err == nil || p.Get("name").String() != "edited" {
+		t.Errorf("invalid Reload = %v, %v", p.Snapshot(), err)
+	}
+
+	fs.Chmod("/cfg/app.json", 0600)
+	p.Set("name", "private")
+	if err := p.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := fs.Stat("/cfg/app.json"); err != nil || fi.Mode().Perm() != 0600 {
+		t.Errorf("Save should keep the mode of the file: %v, %v", fi.Mode(), err)
+	}
+
+	afero.WriteFile(fs, "/cfg/app.json.lock", nil, 0644)
+	p, _ = NewPersistentMap("/cfg/other.json", PersistOptions{Fs: fs, LockTimeout: 20 * time.Millisecond})
+	p.path = "/cfg/app.json"
+	if err := p.Save(); !errors.Is(err, ErrLocked) {
+		t.Errorf("Save with a held lock = %v", err)
+	}
+	if _, err := NewPersistentMap("/cfg/app.yaml", PersistOptions{Fs: fs, Format: "yaml"}); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+func TestPersistentMapAutosave(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	p, err := NewPersistentMap("/auto.json", PersistOptions{Fs: fs, Autosave: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		p.Set("n", i)
+	}
+	if ok, _ := afero.Exists(fs, "/auto.json"); ok {
+		t.Error("autosave should be debounced")
+	}
+	time.Sleep(80 * time.Millisecond)
+	data, _ := afero.ReadFile(fs, "/auto.json")
+	if strings.TrimSpace(string(data)) != `{"n":4}` || p.Dirty() {
+		t.Errorf("autosaved %s", data)
+	}
+
+	p.Del("n")
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = afero.ReadFile(fs, "/auto.json")
+	if string(data) != `{}` {
+		t.Errorf("Close saved %s", data)
+	}
+}
@@ -0,0 +1,217 @@
+package godao
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
+
+// projection is a parsed Project expression.
+type projection struct {
+	path       string
+	alias      string
+	exclude    bool
+	def        any
+	hasDefault bool
+}
+
+// parseProjection parses "path [as alias] [= default]" or "-pattern".
+func parseProjection(expr string) (p projection, err error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(expr, "-") {
+		p.exclude = true
+		p.path = strings.TrimSpace(expr[1:])
+		if p.path == "" {
+			err = fmt.Errorf("empty exclusion")
+		}
+		return
+	}
+	if i := strings.Index(expr, " = "); i > -1 {
+		def := strings.TrimSpace(expr[i+3:])
+		if e := json.Decode([]byte(def), &p.def); e != nil {
+			p.def = def
+		}
+		p.hasDefault = true
+		expr = strings.TrimSpace(expr[:i])
+	}
+	if i := strings.Index(expr, " as "); i > -1 {
+		p.alias = strings.TrimSpace(expr[i+4:])
+		expr = strings.TrimSpace(expr[:i])
+		if p.alias == "" {
+			return p, fmt.Errorf("empty alias in %q", expr)
+		}
+	}
+	p.path = expr
+	if p.path == "" {
+		err = fmt.Errorf("empty path")
+	}
+	return
+}
+
+// Project returns a new Map shaped by the expressions:
+//
+//	"user.name"              // copies the value, keeping its nesting
+//	"users.#.email"          // copies email from every array element
+//	"settings.*"             // wildcards copy every matching key, see Match
+//	"user.name as name"      // stores the value of any Get path under name
+//	"user.role = \"guest\""  // default when the path is missing, JSON or plain text
+//	"-*password"             // drops the matching dotted paths from the result
+//
+// With only exclusions, the projection starts from a copy of m.
+func (m Map) Project(exprs ...string) (Map, error) {
+	return m.project(exprs, false)
+}
+
+func (m Map) project(exprs []string, lenient bool) (o Map, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	var includes, excludes []projection
+	for _, e := range exprs {
+		p, perr := parseProjection(e)
+		if perr != nil {
+			if lenient {
+				continue
+			}
+			return nil, fmt.Errorf("project %q: %w", e, perr)
+		}
+		if p.exclude {
+			excludes = append(excludes, p)
+		} else {
+			includes = append(includes, p)
+		}
+	}
+
+	if len(includes) == 0 {
+		o = m.Clone()
+	} else {
+		o = Map{}
+	}
+	for _, p := range includes {
+		found := false
+		if p.alias != "" {
+			if r := m.get(p.path); r.Exists() {
+				found = o.set(p.alias, cloneValue(r.Value())) == nil
+			}
+		} else if segs, ok := parsePath(p.path); ok {
+			var v any
+			if v, found = projectPath(m, segs); found {
+				mergeProjection(o, v)
+			}
+		} else if r := m.get(p.path); r.Exists() {
+			found = o.set(p.path, cloneValue(r.Value())) == nil
+		}
+		if !found && p.hasDefault {
+			target := p.alias
+			if target == "" {
+				target = p.path
+			}
+			if err = o.set(target, cloneValue(p.def)); err != nil && !lenient {
+				return nil, fmt.Errorf("project %q: %w", p.path, err)
+			}
+			err = nil
+		}
+	}
+	for _, p := range excludes {
+		exclude(o, "", p.path)
+	}
+	return
+}
+
+// projectPath copies the parts of v selected by segs into a new value of the
+// same shape.
+func projectPath(v any, segs []pathSegment) (any, bool) {
+	if len(segs) == 0 {
+		return cloneValue(v), true
+	}
+	seg, rest := segs[0], segs[1:]
+	if obj, ok := asObject(v); ok {
+		out := Map{}
+		for k, e := range obj {
+			if (seg.wild && Match(k, seg.raw)) || (!seg.wild && k == seg.key) {
+				if pv, ok := projectPath(e, rest); ok {
+					out[k] = pv
+				}
+			}
+		}
+		return out, len(out) > 0
+	}
+	if arr, ok := asArray(v); ok {
+		if seg.key == "#" {
+			// elements without a match keep their position as empty objects
+			out := make([]any, len(arr))
+			for x, e := range arr {
+				if pv, ok := projectPath(e, rest); ok {
+					out[x] = pv
+				} else {
+					out[x] = Map{}
+				}
+			}
+			return out, true
+		}
+		i, ok := arrayIndex(seg.key)
+		if !ok || i >= len(arr) {
+			return nil, false
+		}
+		pv, ok := projectPath(arr[i], rest)
+		out := make([]any, i+1)
+		out[i] = pv
+		return out, ok
+	}
+	return nil, false
+}
+
+// mergeProjection merges a projected value into dst, combining objects by key
+// and arrays by index.
+func mergeProjection(dst, src any) any {
+	if do, ok := asObject(dst); ok {
+		if so, ok := asObject(src); ok {
+			for k, v := range so {
+				if e, found := do[k]; found {
+					do[k] = mergeProjection(e, v)
+				} else {
+					do[k] = v
+				}
+			}
+			return dst
+		}
+	}
+	if da, ok := dst.([]any); ok {
+		if sa, ok := src.([]any); ok {
+			for x, v := range sa {
+				switch {
+				case x >= len(da):
+					da = append(da, v)
+				case v != nil:
+					da[x] = mergeProjection(da[x], v)
+				}
+			}
+			return da
+		}
+	}
+	if src == nil {
+		return dst
+	}
+	return src
+}
+
+// exclude removes the keys of v whose dotted path matches pattern.
+func exclude(v any, prefix, pattern string) {
+	if obj, ok := asObject(v); ok {
+		for k, e := range obj {
+			path := prefix + k
+			if Match(path, pattern) {
+				delete(obj, k)
+				continue
+			}
+			exclude(e, path+".", pattern)
+		}
+		return
+	}
+	if arr, ok := v.([]any); ok {
+		for x, e := range arr {
+			exclude(e, prefix+strconv.Itoa(x)+".", pattern)
+		}
+	}
+}
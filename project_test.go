@@ -0,0 +1,44 @@
+package godao
+
+import (
+	"testing"
+)
+
+func TestProject(t *testing.T) {
+	m := Map{
+		"user": Map{"name": "jo", "password": "x", "meta": Map{"a": 1, "b": 2, "c": 3}},
+		"items": []any{
+			Map{"id": 1, "name": "one", "secret": true},
+			Map{"id": 2, "secret": true},
+		},
+	}
+	got, err := m.Project(
+		"user.name as name",
+		"user.meta.*",
+		"-user.meta.c",
+		"items.#.id",
+		"items.#.name",
+		"user.role as role = \"guest\"",
+		"user.limits = {\"max\": 5}",
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{
+		"name":  "jo",
+		"role":  "guest",
+		"user":  Map{"meta": Map{"a": 1, "b": 2}, "limits": Map{"max": 5.0}},
+		"items": []any{Map{"id": 1, "name": "one"}, Map{"id": 2}},
+	}
+	if d := got.Diff(want); len(d) > 0 {
+		t.Errorf("projection differs: %s", d.Bytes())
+	}
+
+	got = m.Selective("-user.password", "-items.*.secret")
+	if got.Get("user.password").Exists() || m.Get("user.password").Value() != "x" {
+		t.Error("password not excluded or original modified")
+	}
+	if got.Get("items.1.secret").Exists() || !got.Get("items.1.id").Exists() {
+		t.Errorf("array exclusion = %v", got["items"])
+	}
+}
@@ -0,0 +1,264 @@
+package godao
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/spf13/afero"
+)
+
+var (
+	ErrUnresolved = errors.New("unresolved expression")
+	ErrCycle      = errors.New("reference cycle")
+)
+
+// ResolveError reports an expression of the value at Path that could not be
+// expanded.
+type ResolveError struct {
+	Path string
+	Expr string
+	Err  error
+}
+
+func (e *ResolveError) Error() string {
+	return fmt.Sprintf("%q: ${%s}: %s", e.Path, e.Expr, e.Err)
+}
+
+func (e *ResolveError) Unwrap() error {
+	return e.Err
+}
+
+// Provider returns the value of key for the expressions of its prefix, ok is
+// false when there is none.
+type Provider func(key string) (v any, ok bool, err error)
+
+// EnvProvider reads environment variables, ie: ${env:HOME}.
+func EnvProvider(key string) (v any, ok bool, err error) {
+	v, ok = os.LookupEnv(key)
+	return
+}
+
+// FileProvider reads files of fs, without their trailing newline, ie:
+// ${file:/run/secrets/db}.
+func FileProvider(fs afero.Fs) Provider {
+	return func(key string) (v any, ok bool, err error) {
+		data, err := afero.ReadFile(fs, key)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return
+		}
+		return strings.TrimRight(string(data), "\r\n"), true, nil
+	}
+}
+
+// ResolveOptions configures Resolve.
+type ResolveOptions struct {
+	// Providers by prefix, they are added to or replace the default "env"
+	// and "file" providers.
+	Providers map[string]Provider
+	// Fs is read by the default file provider, defaults to the OS file system.
+	Fs afero.Fs
+}
+
+type resolver struct {
+	src       Map
+	providers map[string]Provider
+	done      map[string]any
+	stack     []string
+	cycle     string // path where the last cycle was detected
+}
+
+// Resolve returns a copy of m whose string values have their expressions
+// expanded, m is left intact:
+//
+//	"${env:HOME}"             // a provider value, "prefix:key"
+//	"${db.host}"              // the value at another path, resolved as well
+//	"${db.port:-5432}"        // a default when there is no value, can nest
+//	"$${literal}"             // an escaped "${literal}"
+//
+// A string made of a single expression takes the type of its value, ie:
+// "${db.port}" can be a number or an object. Otherwise values are formatted
+// into the string. References forming a cycle and expressions without value
+// nor default fail with a *ResolveError.
+func (m Map) Resolve(opts ...ResolveOptions) (res Map, err error) {
+	var opt ResolveOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Fs == nil {
+		opt.Fs = afero.NewOsFs()
+	}
+	r := &resolver{
+		providers: map[string]Provider{"env": EnvProvider, "file": FileProvider(opt.Fs)},
+		done:      map[string]any{},
+	}
+	for k, p := range opt.Providers {
+		r.providers[k] = p
+	}
+	mu.RLock()
+	r.src = m.Clone()
+	mu.RUnlock()
+
+	// sorted, so a cycle is reported from the same path every time
+	keys := MapKeys(r.src)
+	sort.Strings(keys)
+	res = make(Map, len(r.src))
+	for _, k := range keys {
+		if res[k], err = r.ref(EscapePathKey(k)); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// ref resolves the value at path.
+func (r *resolver) ref(path string) (v any, err error) {
+	if v, ok := r.done[path]; ok {
+		return cloneValue(v), nil
+	}
+	for x, p := range r.stack {
+		if p == path {
+			r.cycle = path
+			chain := strings.Join(append(r.stack[x:len(r.stack):len(r.stack)], path), " -> ")
+			return nil, fmt.Errorf("%w: %s", ErrCycle, chain)
+		}
+	}
+	r.stack = append(r.stack, path)
+	v, err = r.value(path, r.src.get(path).Value())
+	r.stack = r.stack[:len(r.stack)-1]
+	if err == nil {
+		r.done[path] = v
+	}
+	return
+}
+
+func (r *resolver) value(path string, v any) (any, error) {
+	if s, ok := v.(string); ok {
+		return r.expand(path, s)
+	}
+	if obj, ok := asObject(v); ok {
+		out := make(Map, len(obj))
+		for k, e := range obj {
+			val, err := r.value(joinPath(path, k), e)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = val
+		}
+		return out, nil
+	}
+	if arr, ok := asArray(v); ok {
+		out := make([]any, len(arr))
+		for x, e := range arr {
+			val, err := r.value(joinPath(path, strconv.Itoa(x)), e)
+			if err != nil {
+				return nil, err
+			}
+			out[x] = val
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// expand replaces the expressions of s.
+func (r *resolver) expand(path, s string) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var b strings.Builder
+	for x := 0; x < len(s); {
+		if strings.HasPrefix(s[x:], "$${") {
+			b.WriteString("${")
+			x += 3
+			continue
+		}
+		if !strings.HasPrefix(s[x:], "${") {
+			b.WriteByte(s[x])
+			x++
+			continue
+		}
+		end := closingBrace(s, x+2)
+		if end < 0 {
+			return nil, &ResolveError{Path: path, Expr: s[x+2:], Err: errors.New("missing closing brace")}
+		}
+		v, err := r.eval(path, s[x+2:end])
+		if err != nil {
+			return nil, err
+		}
+		if x == 0 && end == len(s)-1 {
+			return v, nil
+		}
+		b.WriteString(stringify(v))
+		x = end + 1
+	}
+	return b.String(), nil
+}
+
+// closingBrace returns the index of the brace closing the expression starting
+// at from, nested expressions included.
+func closingBrace(s string, from int) int {
+	depth := 0
+	for x := from; x < len(s); x++ {
+		switch {
+		case strings.HasPrefix(s[x:], "${"):
+			depth++
+			x++
+		case s[x] == '}':
+			if depth == 0 {
+				return x
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+func (r *resolver) eval(path, expr string) (v any, err error) {
+	name, def, hasDef := strings.Cut(expr, ":-")
+	var ok bool
+	if prefix, key, isProvider := strings.Cut(name, ":"); isProvider && r.providers[prefix] != nil {
+		if v, ok, err = r.providers[prefix](key); err != nil {
+			return nil, &ResolveError{Path: path, Expr: expr, Err: err}
+		}
+		v = cloneValue(v)
+	} else if r.src.get(name).Exists() {
+		if v, err = r.ref(name); err != nil {
+			// a cycle is reported by the reference it started from
+			var re *ResolveError
+			if !errors.As(err, &re) && (!errors.Is(err, ErrCycle) || r.stack[len(r.stack)-1] == r.cycle) {
+				err = &ResolveError{Path: path, Expr: expr, Err: err}
+			}
+			return nil, err
+		}
+		ok = true
+	}
+	if ok {
+		return
+	}
+	if hasDef {
+		return r.expand(path, def)
+	}
+	return nil, &ResolveError{Path: path, Expr: expr, Err: ErrUnresolved}
+}
+
+func stringify(v any) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return fmt.Sprint(val)
+	}
+	return string(json.Encode(v))
+}
@@ -0,0 +1,86 @@
+package godao
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestResolve(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/run/secrets/db", []byte("s3cret\n"), 0600)
+	t.Setenv("DAO_TEST_HOME", "/home/dao")
+	m := Map{
+		"home": "${env:DAO_TEST_HOME}/cfg",
+		"db": Map{
+			"host":     "db.local",
+			"port":     5432,
+			"password": "${file:/run/secrets/db}",
+			"url":      "pg://${db.host}:${db.port}/${name:-app}",
+		},
+		"port":    "${db.port}",
+		"copy":    "${db}",
+		"tags":    []any{"${env:DAO_TEST_MISSING:-${db.host}}", "$${literal}"},
+		"region":  "${cloud:region}",
+		"missing": "${nope:-}",
+	}
+	res, err := m.Resolve(ResolveOptions{Fs: fs, Providers: map[string]Provider{
+		"cloud": func(key string) (any, bool, error) { return "eu-" + key, true, nil },
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]string{
+		"home":        "/home/dao/cfg",
+		"db.password": "s3cret",
+		"db.url":      "pg://db.local:5432/app",
+		"copy.url":    "pg://db.local:5432/app",
+		"tags.0":      "db.local",
+		"tags.1":      "${literal}",
+		"region":      "eu-region",
+		"missing":     "",
+	} {
+		if got := res.Get(path).String(); got != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+	res.Get("copy").Value().(Map)["host"] = "changed"
+	if res.Get("db.host").String() != "db.local" {
+		t.Error("a referenced object is shared in the result")
+	}
+	if res.Get("port").Value() != 5432 {
+		t.Errorf("port = %#v, the type should be kept", res.Get("port").Value())
+	}
+	if m.Get("port").String() != "${db.port}" || m.Get("db.url").String() != "pg://${db.host}:${db.port}/${name:-app}" {
+		t.Error("Resolve changed the original map")
+	}
+
+	_, err = Map{"a": "${b}", "b": Map{"c": "x${a}"}}.Resolve()
+	var re *ResolveError
+	if !errors.Is(err, ErrCycle) || !errors.As(err, &re) {
+		t.Errorf("cycle = %v", err)
+	}
+	_, err = Map{"a": "${b:-x}", "b": "${a:-y}"}.Resolve()
+	if !errors.As(err, &re) || re.Path != "a" || re.Expr != "b:-x" {
+		t.Errorf("cycle = %v, want it reported at a", err)
+	}
+	if _, err = (Map{"a": "${b.c}"}).Resolve(); !errors.Is(err, ErrUnresolved) {
+		t.Errorf("unresolved = %v", err)
+	}
+	if _, err = (Map{"a": "${b"}).Resolve(); err == nil {
+		t.Error("unterminated expression should fail")
+	}
+
+	shared := Map{"k": 1}
+	res, err = Map{"a": "${p:x}", "b": "${p:x}"}.Resolve(ResolveOptions{Providers: map[string]Provider{
+		"p": func(string) (any, bool, error) { return shared, true, nil },
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res["a"].(Map)["k"] = 2
+	if shared["k"] != 1 || res.Get("b.k").Value() != 1 {
+		t.Error("provider values are shared in the result")
+	}
+}
@@ -0,0 +1,544 @@
+// Package schema validates Map, RawValue and Result values against a subset
+// of JSON Schema draft 2020-12: type, const, enum, properties,
+// additionalProperties, required, minimum/maximum (and exclusive variants),
+// minLength/maxLength, pattern, items, prefixItems, minItems/maxItems,
+// allOf/anyOf/oneOf/not and $ref to locations inside the same document.
+package schema
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	godao "github.com/hyprstereo/go-dao"
+	"github.com/hyprstereo/go-dao/encoding/json"
+)
+
+// Violation is a single failed keyword, Location is a JSON Pointer into the
+// validated value.
+type Violation struct {
+	Location string `json:"location"`
+	Keyword  string `json:"keyword"`
+	Message  string `json:"message"`
+}
+
+func (v Violation) String() string {
+	loc := v.Location
+	if loc == "" {
+		loc = "/"
+	}
+	return fmt.Sprintf("%s: %s", loc, v.Message)
+}
+
+// ValidationError holds every violation found.
+type ValidationError []Violation
+
+func (e ValidationError) Error() string {
+	msgs := make([]string, len(e))
+	for x, v := range e {
+		msgs[x] = v.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Schema is a compiled JSON Schema document.
+type Schema struct {
+	root  *node
+	doc   any
+	nodes map[string]*node
+}
+
+type node struct {
+	schema *Schema
+	ptr    string
+	bool   *bool
+	kw     map[string]any
+	ref    string
+	re     *regexp.Regexp
+	props  map[string]*node
+	addl   *node
+	items  *node
+	prefix []*node
+	allOf  []*node
+	anyOf  []*node
+	oneOf  []*node
+	not    *node
+}
+
+// Compile parses a schema given as Map, map[string]any, RawValue, []byte or
+// string.
+func Compile(src any) (s *Schema, err error) {
+	var doc any
+	switch v := src.(type) {
+	case json.RawValue:
+		err = json.Decode(v, &doc)
+	case []byte:
+		err = json.Decode(v, &doc)
+	case string:
+		err = json.Decode([]byte(v), &doc)
+	case godao.Result:
+		doc = normalize(v.Value())
+	default:
+		doc = normalize(v)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+	s = &Schema{doc: doc, nodes: map[string]*node{}}
+	if s.root, err = s.compile(doc, ""); err != nil {
+		return nil, err
+	}
+	if err = s.checkCycles(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkCycles rejects the schemas applying to the same value as themselves,
+// through $ref, allOf, anyOf, oneOf or not, which would never stop.
+func (s *Schema) checkCycles() error {
+	ptrs := make([]string, 0, len(s.nodes))
+	for p := range s.nodes {
+		ptrs = append(ptrs, p)
+	}
+	sort.Strings(ptrs)
+	state := map[*node]int{} // 1 visiting, 2 done
+	var visit func(n *node) error
+	visit = func(n *node) error {
+		switch state[n] {
+		case 1:
+			return fmt.Errorf("schema %s: $ref cycle", pointerOrRoot(n.ptr))
+		case 2:
+			return nil
+		}
+		state[n] = 1
+		for _, c := range n.inPlace() {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+		state[n] = 2
+		return nil
+	}
+	for _, p := range ptrs {
+		if err := visit(s.nodes[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inPlace returns the subschemas applied to the same value as n.
+func (n *node) inPlace() (nodes []*node) {
+	if n.ref != "" || n.kw["$ref"] != nil {
+		nodes = append(nodes, n.schema.nodes[n.ref])
+	}
+	nodes = append(nodes, n.allOf...)
+	nodes = append(nodes, n.anyOf...)
+	nodes = append(nodes, n.oneOf...)
+	if n.not != nil {
+		nodes = append(nodes, n.not)
+	}
+	return
+}
+
+// MustCompile is like Compile but panics on error.
+func MustCompile(src any) *Schema {
+	s, err := Compile(src)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func (s *Schema) compile(doc any, ptr string) (n *node, err error) {
+	if n, ok := s.nodes[ptr]; ok {
+		return n, nil
+	}
+	n = &node{schema: s, ptr: ptr}
+	s.nodes[ptr] = n
+	if b, ok := doc.(bool); ok {
+		n.bool = &b
+		return
+	}
+	kw, ok := doc.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("schema %s: expected object or boolean", pointerOrRoot(ptr))
+	}
+	n.kw = kw
+	if ref, ok := kw["$ref"].(string); ok {
+		if !strings.HasPrefix(ref, "#") {
+			return nil, fmt.Errorf("schema %s: only local $ref is supported, got %q", pointerOrRoot(ptr), ref)
+		}
+		n.ref = strings.TrimPrefix(ref, "#")
+		target, err := resolve(s.doc, n.ref)
+		if err != nil {
+			return nil, fmt.Errorf("schema %s: %w", pointerOrRoot(ptr), err)
+		}
+		if _, err = s.compile(target, n.ref); err != nil {
+			return nil, err
+		}
+	}
+	if p, ok := kw["pattern"].(string); ok {
+		if n.re, err = regexp.Compile(p); err != nil {
+			return nil, fmt.Errorf("schema %s/pattern: %w", ptr, err)
+		}
+	}
+	if props, ok := kw["properties"].(map[string]any); ok {
+		n.props = map[string]*node{}
+		for k, v := range props {
+			if n.props[k], err = s.compile(v, ptr+"/properties/"+escape(k)); err != nil {
+				return
+			}
+		}
+	}
+	if v, ok := kw["additionalProperties"]; ok {
+		if n.addl, err = s.compile(v, ptr+"/additionalProperties"); err != nil {
+			return
+		}
+	}
+	if v, ok := kw["items"]; ok {
+		if n.items, err = s.compile(v, ptr+"/items"); err != nil {
+			return
+		}
+	}
+	if v, ok := kw["not"]; ok {
+		if n.not, err = s.compile(v, ptr+"/not"); err != nil {
+			return
+		}
+	}
+	lists := []struct {
+		name string
+		dst  *[]*node
+	}{{"prefixItems", &n.prefix}, {"allOf", &n.allOf}, {"anyOf", &n.anyOf}, {"oneOf", &n.oneOf}}
+	for _, l := range lists {
+		arr, ok := kw[l.name].([]any)
+		if !ok {
+			continue
+		}
+		for x, v := range arr {
+			c, err := s.compile(v, ptr+"/"+l.name+"/"+strconv.Itoa(x))
+			if err != nil {
+				return nil, err
+			}
+			*l.dst = append(*l.dst, c)
+		}
+	}
+	for _, name := range []string{"$defs", "definitions"} {
+		if defs, ok := kw[name].(map[string]any); ok {
+			for k, v := range defs {
+				if _, err = s.compile(v, ptr+"/"+name+"/"+escape(k)); err != nil {
+					return
+				}
+			}
+		}
+	}
+	return
+}
+
+// Validate checks v, which may be a Map, RawValue, Result or any decoded JSON
+// value. It returns a ValidationError listing every violation, or nil.
+func (s *Schema) Validate(v any) error {
+	var doc any
+	switch val := v.(type) {
+	case json.RawValue:
+		if err := json.Decode(val, &doc); err != nil {
+			return ValidationError{{Keyword: "json", Message: err.Error()}}
+		}
+	case godao.Result:
+		doc = normalize(val.Value())
+	default:
+		doc = normalize(v)
+	}
+	var errs ValidationError
+	s.root.validate(doc, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Valid reports whether v passes validation.
+func (s *Schema) Valid(v any) bool {
+	return s.Validate(v) == nil
+}
+
+func (n *node) fail(errs *ValidationError, loc, keyword, format string, args ...any) {
+	*errs = append(*errs, Violation{Location: loc, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+func (n *node) validate(v any, loc string, errs *ValidationError) {
+	if n.bool != nil {
+		if !*n.bool {
+			n.fail(errs, loc, "false", "no value is allowed")
+		}
+		return
+	}
+	kw := n.kw
+	if n.ref != "" || kw["$ref"] != nil {
+		n.schema.nodes[n.ref].validate(v, loc, errs)
+	}
+	if t, ok := kw["type"]; ok && !matchType(v, t) {
+		n.fail(errs, loc, "type", "expected %v, got %s", t, typeOf(v))
+		return
+	}
+	if c, ok := kw["const"]; ok && !equal(v, c) {
+		n.fail(errs, loc, "const", "must be %v", c)
+	}
+	if enum, ok := kw["enum"].([]any); ok {
+		found := false
+		for _, e := range enum {
+			if equal(v, e) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			n.fail(errs, loc, "enum", "must be one of %v", enum)
+		}
+	}
+	switch val := v.(type) {
+	case float64:
+		n.validateNumber(val, loc, errs)
+	case string:
+		n.validateString(val, loc, errs)
+	case []any:
+		n.validateArray(val, loc, errs)
+	case map[string]any:
+		n.validateObject(val, loc, errs)
+	}
+	for _, c := range n.allOf {
+		c.validate(v, loc, errs)
+	}
+	if len(n.anyOf) > 0 && n.count(n.anyOf, v, loc) == 0 {
+		n.fail(errs, loc, "anyOf", "does not match any schema")
+	}
+	if len(n.oneOf) > 0 {
+		if c := n.count(n.oneOf, v, loc); c != 1 {
+			n.fail(errs, loc, "oneOf", "matches %d schemas, expected exactly one", c)
+		}
+	}
+	if n.not != nil && n.count([]*node{n.not}, v, loc) == 1 {
+		n.fail(errs, loc, "not", "must not match the schema")
+	}
+}
+
+// count returns how many of nodes accept v.
+func (n *node) count(nodes []*node, v any, loc string) (c int) {
+	for _, sub := range nodes {
+		var e ValidationError
+		sub.validate(v, loc, &e)
+		if len(e) == 0 {
+			c++
+		}
+	}
+	return
+}
+
+func (n *node) validateNumber(v float64, loc string, errs *ValidationError) {
+	if min, ok := n.kw["minimum"].(float64); ok && v < min {
+		n.fail(errs, loc, "minimum", "must be >= %v", min)
+	}
+	if max, ok := n.kw["maximum"].(float64); ok && v > max {
+		n.fail(errs, loc, "maximum", "must be <= %v", max)
+	}
+	if min, ok := n.kw["exclusiveMinimum"].(float64); ok && v <= min {
+		n.fail(errs, loc, "exclusiveMinimum", "must be > %v", min)
+	}
+	if max, ok := n.kw["exclusiveMaximum"].(float64); ok && v >= max {
+		n.fail(errs, loc, "exclusiveMaximum", "must be < %v", max)
+	}
+	if m, ok := n.kw["multipleOf"].(float64); ok && m > 0 {
+		// decimal multiples are not exact in binary, ie: 0.3 / 0.1
+		if q := v / m; math.Abs(q-math.Round(q)) > 1e-9*math.Max(1, math.Abs(q)) {
+			n.fail(errs, loc, "multipleOf", "must be a multiple of %v", m)
+		}
+	}
+}
+
+func (n *node) validateString(v string, loc string, errs *ValidationError) {
+	l := float64(utf8.RuneCountInString(v))
+	if min, ok := n.kw["minLength"].(float64); ok && l < min {
+		n.fail(errs, loc, "minLength", "must be at least %v characters", min)
+	}
+	if max, ok := n.kw["maxLength"].(float64); ok && l > max {
+		n.fail(errs, loc, "maxLength", "must be at most %v characters", max)
+	}
+	if n.re != nil && !n.re.MatchString(v) {
+		n.fail(errs, loc, "pattern", "must match %q", n.re.String())
+	}
+}
+
+func (n *node) validateArray(v []any, loc string, errs *ValidationError) {
+	l := float64(len(v))
+	if min, ok := n.kw["minItems"].(float64); ok && l < min {
+		n.fail(errs, loc, "minItems", "must have at least %v items", min)
+	}
+	if max, ok := n.kw["maxItems"].(float64); ok && l > max {
+		n.fail(errs, loc, "maxItems", "must have at most %v items", max)
+	}
+	for x, e := range v {
+		switch {
+		case x < len(n.prefix):
+			n.prefix[x].validate(e, loc+"/"+strconv.Itoa(x), errs)
+		case n.items != nil:
+			n.items.validate(e, loc+"/"+strconv.Itoa(x), errs)
+		}
+	}
+}
+
+func (n *node) validateObject(v map[string]any, loc string, errs *ValidationError) {
+	if req, ok := n.kw["required"].([]any); ok {
+		for _, r := range req {
+			if k, ok := r.(string); ok {
+				if _, found := v[k]; !found {
+					n.fail(errs, loc, "required", "missing property %q", k)
+				}
+			}
+		}
+	}
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if p, ok := n.props[k]; ok {
+			p.validate(v[k], loc+"/"+escape(k), errs)
+		} else if n.addl != nil {
+			if n.addl.bool != nil && !*n.addl.bool {
+				n.fail(errs, loc+"/"+escape(k), "additionalProperties", "property %q is not allowed", k)
+				continue
+			}
+			n.addl.validate(v[k], loc+"/"+escape(k), errs)
+		}
+	}
+}
+
+func matchType(v any, t any) bool {
+	switch tt := t.(type) {
+	case string:
+		return isType(v, tt)
+	case []any:
+		for _, e := range tt {
+			if s, ok := e.(string); ok && isType(v, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isType(v any, t string) bool {
+	switch t {
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	}
+	return typeOf(v) == t
+}
+
+func typeOf(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func equal(a, b any) bool {
+	return string(json.Encode(a)) == string(json.Encode(b))
+}
+
+// normalize converts v into the decoded JSON shape: map[string]any, []any,
+// float64, string, bool and nil.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case nil, bool, string, float64:
+		return val
+	case godao.Map:
+		return normalize(map[string]any(val))
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, e := range val {
+			out[k] = normalize(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for x, e := range val {
+			out[x] = normalize(e)
+		}
+		return out
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case float32:
+		return float64(val)
+	}
+	var out any
+	if err := json.Decode(json.Encode(v), &out); err != nil {
+		return v
+	}
+	return out
+}
+
+// resolve follows a JSON Pointer inside doc.
+func resolve(doc any, ptr string) (any, error) {
+	if ptr == "" {
+		return doc, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("unsupported $ref fragment %q", ptr)
+	}
+	cur := doc
+	for _, t := range strings.Split(ptr[1:], "/") {
+		t = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+		switch c := cur.(type) {
+		case map[string]any:
+			v, ok := c[t]
+			if !ok {
+				return nil, fmt.Errorf("$ref #%s not found", ptr)
+			}
+			cur = v
+		case []any:
+			i, err := strconv.Atoi(t)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, fmt.Errorf("$ref #%s not found", ptr)
+			}
+			cur = c[i]
+		default:
+			return nil, fmt.Errorf("$ref #%s not found", ptr)
+		}
+	}
+	return cur, nil
+}
+
+func escape(k string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1")
+}
+
+func pointerOrRoot(ptr string) string {
+	if ptr == "" {
+		return "#"
+	}
+	return "#" + ptr
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	godao "github.com/hyprstereo/go-dao"
+)
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		schema string
+		valid  any
+		bad    any
+		kw     string
+	}{
+		{`{"type": "string"}`, "x", 1, "type"},
+		{`{"type": ["integer", "null"]}`, nil, 1.5, "type"},
+		{`{"const": {"a": 1}}`, godao.Map{"a": 1}, godao.Map{"a": 2}, "const"},
+		{`{"enum": ["a", 1]}`, 1, "b", "enum"},
+		{`{"minimum": 1}`, 1, 0, "minimum"},
+		{`{"maximum": 1}`, 1, 2, "maximum"},
+		{`{"exclusiveMinimum": 1}`, 2, 1, "exclusiveMinimum"},
+		{`{"exclusiveMaximum": 1}`, 0, 1, "exclusiveMaximum"},
+		{`{"multipleOf": 0.5}`, 1.5, 1.2, "multipleOf"},
+		{`{"multipleOf": 0.1}`, 0.3, 0.35, "multipleOf"},
+		{`{"multipleOf": 0.01}`, 0.07, 0.075, "multipleOf"},
+		{`{"minLength": 2}`, "éé", "é", "minLength"},
+		{`{"maxLength": 1}`, "é", "ab", "maxLength"},
+		{`{"pattern": "^a+$"}`, "aa", "ab", "pattern"},
+		{`{"minItems": 1}`, []any{1}, []any{}, "minItems"},
+		{`{"maxItems": 1}`, []any{1}, []any{1, 2}, "maxItems"},
+		{`{"items": {"type": "number"}}`, []int{1, 2}, []any{1, "x"}, "type"},
+		{`{"prefixItems": [{"type": "string"}], "items": false}`, []any{"a"}, []any{"a", 1}, "false"},
+		{`{"required": ["a"]}`, godao.Map{"a": nil}, godao.Map{"b": 1}, "required"},
+		{`{"properties": {"a": {"type": "boolean"}}}`, godao.Map{"a": true}, godao.Map{"a": 1}, "type"},
+		{`{"additionalProperties": false}`, godao.Map{}, godao.Map{"a": 1}, "additionalProperties"},
+		{`{"additionalProperties": {"type": "string"}}`, godao.Map{"a": "x"}, godao.Map{"a": 1}, "type"},
+		{`{"allOf": [{"minimum": 1}, {"maximum": 3}]}`, 2, 4, "maximum"},
+		{`{"anyOf": [{"type": "string"}, {"minimum": 1}]}`, "x", 0, "anyOf"},
+		{`{"oneOf": [{"minimum": 1}, {"maximum": 3}]}`, 4, 2, "oneOf"},
+		{`{"not": {"type": "null"}}`, 1, nil, "not"},
+	}
+	for _, tt := range tests {
+		s, err := Compile(tt.schema)
+		if err != nil {
+			t.Errorf("%s: %v", tt.schema, err)
+			continue
+		}
+		if err := s.Validate(tt.valid); err != nil {
+			t.Errorf("%s: %#v: %v", tt.schema, tt.valid, err)
+		}
+		var ve ValidationError
+		if err := s.Validate(tt.bad); !errors.As(err, &ve) || len(ve) != 1 || ve[0].Keyword != tt.kw {
+			t.Errorf("%s: %#v: got %v, want a %s violation", tt.schema, tt.bad, err, tt.kw)
+		}
+	}
+}
+
+func TestRefAndLocations(t *testing.T) {
+	s := MustCompile(godao.Map{
+		"$defs": godao.Map{
+			"port": godao.Map{"type": "integer", "minimum": 1, "maximum": 65535},
+			"node": godao.Map{
+				"type":       "object",
+				"properties": godao.Map{"children": godao.Map{"type": "array", "items": godao.Map{"$ref": "#/$defs/node"}}},
+			},
+		},
+		"type":     "object",
+		"required": []any{"name"},
+		"properties": godao.Map{
+			"ports": godao.Map{"type": "array", "items": godao.Map{"$ref": "#/$defs/port"}},
+			"a/b":   godao.Map{"type": "string"},
+			"tree":  godao.Map{"$ref": "#/$defs/node"},
+		},
+	})
+	doc := godao.Map{
+		"ports": []any{80, 0, "x"},
+		"a/b":   1,
+		"tree":  godao.Map{"children": []any{godao.Map{"children": 1}}},
+	}
+	var ve ValidationError
+	if err := s.Validate(doc); !errors.As(err, &ve) {
+		t.Fatalf("Validate = %v", err)
+	}
+	want := map[string]string{
+		"":                          "required",
+		"/ports/1":                  "minimum",
+		"/ports/2":                  "type",
+		"/a~1b":                     "type",
+		"/tree/children/0/children": "type",
+	}
+	if len(ve) != len(want) {
+		t.Errorf("violations = %v", ve)
+	}
+	for _, v := range ve {
+		if want[v.Location] != v.Keyword {
+			t.Errorf("unexpected violation %s (%s)", v, v.Keyword)
+		}
+	}
+
+	if !s.Valid(godao.Map{"name": "x", "ports": []int{443}}.Get("@this")) {
+		t.Error("a Result should be validated like its value")
+	}
+	if _, err := Compile(`{"$ref": "#/$defs/missing"}`); err == nil {
+		t.Error("a missing $ref should fail to compile")
+	}
+	if _, err := Compile(`{"$ref": "other.json#"}`); err == nil {
+		t.Error("a remote $ref should fail to compile")
+	}
+	for _, cyclic := range []string{
+		`{"$ref": "#"}`,
+		`{"$defs": {"a": {"$ref": "#/$defs/a"}}}`,
+		`{"$defs": {"a": {"allOf": [{"$ref": "#/$defs/b"}]}, "b": {"not": {"$ref": "#/$defs/a"}}}}`,
+	} {
+		if _, err := Compile(cyclic); err == nil {
+			t.Errorf("%s: a $ref cycle should fail to compile", cyclic)
+		}
+	}
+}
+
+func TestCompileResult(t *testing.T) {
+	m := godao.Map{"schema": godao.Map{"type": "object", "properties": godao.Map{"n": godao.Map{"maximum": 1}}}}
+	s, err := Compile(m.Get("schema"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Valid(godao.Map{"n": 2}) || !s.Valid(godao.Map{"n": 1}) {
+		t.Error("schema compiled from a Result was not applied")
+	}
+}
@@ -0,0 +1,24 @@
+package godao
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamMaps(t *testing.T) {
+	src := `{"meta": {"skip": [1, {"a": [2]}]}, "data": {"items": [{"id": 1}, {"id": 2}, {"id": 3}]}, "after": true}`
+	var ids []int64
+	err := StreamMaps(strings.NewReader(src), "data.items", func(m Map) error {
+		ids = append(ids, m.Get("id").Int())
+		return nil
+	})
+	if err != nil || len(ids) != 3 || ids[2] != 3 {
+		t.Errorf("StreamMaps = %v, %v", ids, err)
+	}
+
+	for _, p := range []string{"data.missing", "meta"} {
+		if err := StreamMaps(strings.NewReader(src), p, func(Map) error { return nil }); err == nil {
+			t.Errorf("StreamMaps(%s) should fail", p)
+		}
+	}
+}
@@ -0,0 +1,231 @@
+package godao
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/iancoleman/strcase"
+)
+
+// StructOptions configures FromStruct.
+type StructOptions struct {
+	// TagName selects the struct tag holding field names. By default the dao
+	// tag is used, then the json tag.
+	TagName string
+	// KeyCase is the casing applied to field names without a tag name, one of
+	// FieldNameCamel, FieldNameSnake or FieldNameKebab.
+	KeyCase uint8
+	// CaseTagged applies KeyCase to tag names as well.
+	CaseTagged bool
+}
+
+// caseKey converts k to the casing policy, ie: "UserID" -> "user_id".
+func caseKey(k string, policy uint8) string {
+	switch policy {
+	case FieldNameSnake:
+		return strcase.ToSnake(k)
+	case FieldNameKebab:
+		return strcase.ToKebab(k)
+	default:
+		return strcase.ToLowerCamel(k)
+	}
+}
+
+// FromStruct converts v, a struct or a pointer to one, into a Map. Nested
+// structs become Maps and slices become []any; time.Time and time.Duration are
+// kept as is and other encoding.TextMarshaler values become strings. Tags
+// follow the json conventions: "-" skips a field, "omitempty" skips empty
+// values and ",inline" or embedding flattens a struct into its parent.
+func FromStruct(v any, opts ...StructOptions) (m Map, err error) {
+	var opt StructOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("from struct: %T is not a struct", v)
+	}
+	e := &encoder{opt: opt, seen: map[uintptr]bool{}}
+	out, err := e.encode(rv)
+	if err != nil {
+		return nil, err
+	}
+	m = out.(Map)
+	return
+}
+
+type encoder struct {
+	opt  StructOptions
+	seen map[uintptr]bool
+}
+
+func (e *encoder) encode(rv reflect.Value) (any, error) {
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	t := rv.Type()
+	switch t {
+	case timeType, durationType:
+		return rv.Interface(), nil
+	}
+	if t.Kind() != reflect.Pointer && t.Kind() != reflect.Interface && t.Implements(marshaler) {
+		text, err := rv.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, fmt.Errorf("from struct: %s: %w", t, err)
+		}
+		return string(text), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if t.Kind() == reflect.Interface {
+			return e.encode(rv.Elem())
+		}
+		ptr := rv.Pointer()
+		if e.seen[ptr] {
+			return nil, fmt.Errorf("from struct: cycle through %s", t)
+		}
+		e.seen[ptr] = true
+		defer delete(e.seen, ptr)
+		return e.encode(rv.Elem())
+	case reflect.Struct:
+		return e.encodeStruct(rv)
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		out := make(Map, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			v, err := e.encode(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			out[fmt.Sprint(iter.Key().Interface())] = v
+		}
+		return out, nil
+	case reflect.Slice:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 {
+			return rv.Bytes(), nil
+		}
+		fallthrough
+	case reflect.Array:
+		out := make([]any, rv.Len())
+		for x := range out {
+			v, err := e.encode(rv.Index(x))
+			if err != nil {
+				return nil, err
+			}
+			out[x] = v
+		}
+		return out, nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Int:
+		return int(rv.Int()), nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return nil, fmt.Errorf("from struct: unsupported type %s", t)
+	}
+	return rv.Interface(), nil
+}
+
+func (e *encoder) encodeStruct(rv reflect.Value) (any, error) {
+	fields := structFields(rv.Type(), e.opt.TagName)
+	out := make(Map, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		v, err := e.encode(fv)
+		if err != nil {
+			return nil, err
+		}
+		name := f.name
+		if !f.tagged || e.opt.CaseTagged {
+			name = caseKey(name, e.opt.KeyCase)
+		}
+		out[name] = v
+	}
+	return out, nil
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, ok is false when it goes
+// through a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for x, i := range index {
+		if x > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(i)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is empty in the sense of json's omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Struct:
+		return false
+	}
+	return v.IsZero()
+}
+
+// RenameKeys returns a copy of m with every key, including those of nested
+// Maps and Maps inside arrays, converted to the casing policy. When two keys
+// collide, the one already in the target casing is kept.
+func (m Map) RenameKeys(policy uint8) Map {
+	mu.RLock()
+	defer mu.RUnlock()
+	return renameKeys(map[string]any(m), policy).(Map)
+}
+
+func renameKeys(v any, policy uint8) any {
+	if obj, ok := asObject(v); ok {
+		keys := MapKeys(obj)
+		sort.Strings(keys)
+		out := make(Map, len(obj))
+		for _, k := range keys {
+			name := caseKey(k, policy)
+			if _, exists := out[name]; exists && name != k {
+				continue
+			}
+			out[name] = renameKeys(obj[k], policy)
+		}
+		return out
+	}
+	if arr, ok := asArray(v); ok {
+		out := make([]any, len(arr))
+		for x, e := range arr {
+			out[x] = renameKeys(e, policy)
+		}
+		return out
+	}
+	return v
+}
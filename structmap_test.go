@@ -0,0 +1,114 @@
+package godao
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type structMeta struct {
+	CreatedAt time.Time `json:"created_at"`
+	Version   int
+}
+
+type structAddress struct {
+	StreetName string
+	ZipCode    string `json:",omitempty"`
+}
+
+type structUser struct {
+	structMeta
+	UserID   int64
+	FullName string           `json:"name"`
+	Secret   string           `json:"-"`
+	Nick     string           `json:"nick,omitempty"`
+	Home     *structAddress   `json:",omitempty"`
+	Work     structAddress    `dao:",inline"`
+	Tags     []string         `json:"tags"`
+	Labels   map[string]uint8 `json:"labels,omitempty"`
+	IP       net.IP           `json:"ip"`
+	Timeout  time.Duration    `json:"timeout"`
+	Friends  []*structAddress `json:"friends"`
+	hidden   bool
+	Extra    map[string]any `json:"extra"`
+}
+
+func TestFromStruct(t *testing.T) {
+	created := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	u := &structUser{
+		structMeta: structMeta{CreatedAt: created, Version: 3},
+		UserID:     7,
+		FullName:   "Jo",
+		Secret:     "x",
+		Work:       structAddress{StreetName: "Main"},
+		Tags:       []string{"a"},
+		IP:         net.ParseIP("10.0.0.1"),
+		Timeout:    time.Second,
+		Friends:    []*structAddress{{StreetName: "Side", ZipCode: "1"}, nil},
+		Extra:      map[string]any{"Nested": structAddress{StreetName: "In"}},
+	}
+	m, err := FromStruct(u, StructOptions{KeyCase: FieldNameSnake})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Map{
+		"created_at":  created,
+		"version":     int(3),
+		"user_id":     int64(7),
+		"name":        "Jo",
+		"street_name": "Main",
+		"tags":        []any{"a"},
+		"ip":          "10.0.0.1",
+		"timeout":     time.Second,
+		"friends":     []any{Map{"street_name": "Side", "zip_code": "1"}, nil},
+		"extra":       Map{"Nested": Map{"street_name": "In"}},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("FromStruct =\n%#v\nwant\n%#v", m, want)
+	}
+
+	m, _ = FromStruct(structAddress{StreetName: "Main", ZipCode: "1"}, StructOptions{KeyCase: FieldNameKebab, CaseTagged: true})
+	if !reflect.DeepEqual(m, Map{"street-name": "Main", "zip-code": "1"}) {
+		t.Errorf("kebab = %v", m)
+	}
+	m, _ = FromStruct(structAddress{StreetName: "Main"})
+	if !reflect.DeepEqual(m, Map{"streetName": "Main"}) {
+		t.Errorf("camel = %v", m)
+	}
+
+	if _, err := FromStruct(42); err == nil {
+		t.Error("FromStruct(42) should fail")
+	}
+	type node struct{ Next *node }
+	n := &node{}
+	n.Next = n
+	if _, err := FromStruct(n); err == nil {
+		t.Error("cycle should fail")
+	}
+}
+
+func TestRenameKeys(t *testing.T) {
+	m := Map{
+		"userId":    1,
+		"user_id":   2,
+		"HomeAddr":  Map{"zip-code": "1"},
+		"past_jobs": []any{Map{"JobTitle": "dev"}, "plain"},
+	}
+	got := m.RenameKeys(FieldNameSnake)
+	want := Map{
+		"user_id":   2,
+		"home_addr": Map{"zip_code": "1"},
+		"past_jobs": []any{Map{"job_title": "dev"}, "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RenameKeys(snake) = %v", got)
+	}
+	back := got.RenameKeys(FieldNameCamel)
+	if back.Get("homeAddr.zipCode").String() != "1" || back.Get("pastJobs.0.jobTitle").String() != "dev" {
+		t.Errorf("RenameKeys(camel) = %v", back)
+	}
+	if _, ok := m["HomeAddr"]; !ok {
+		t.Error("RenameKeys modified the receiver")
+	}
+}
@@ -0,0 +1,167 @@
+package godao
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// SyncMap is a Map guarded by its own lock, so unrelated maps never contend.
+// Iteration works on a snapshot, which makes it safe to read and write the
+// same SyncMap from inside ForEach callbacks. Objects and arrays are copied
+// in and out, so values returned or passed to callbacks can be modified
+// freely.
+type SyncMap struct {
+	mu      sync.RWMutex
+	m       Map
+	version uint64
+}
+
+// NewSyncMap creates a SyncMap, optionally taking ownership of an initial Map.
+func NewSyncMap(initial ...Map) *SyncMap {
+	s := &SyncMap{m: Map{}}
+	if len(initial) > 0 && initial[0] != nil {
+		s.m = initial[0]
+	}
+	return s
+}
+
+// Version is incremented on every write.
+func (s *SyncMap) Version() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.version
+}
+
+func (s *SyncMap) Get(p string, defaultValue ...any) Result {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return detach(s.m.get(p, defaultValue...))
+}
+
+// detach copies the objects and arrays of r out of the map.
+func detach(r Result) Result {
+	if r.native {
+		r.val = cloneValue(r.val)
+	}
+	return r
+}
+
+func (s *SyncMap) Has(p string) bool {
+	return s.Get(p).Exists()
+}
+
+// Set stores value at path p, see Map.Set.
+func (s *SyncMap) Set(p string, value any) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err = s.m.set(p, cloneValue(value)); err == nil {
+		s.version++
+	}
+	return
+}
+
+// Del removes path p and returns the removed value.
+func (s *SyncMap) Del(p string) (res Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ok bool
+	if res, ok = s.m.del(p); ok {
+		s.version++
+	}
+	return
+}
+
+// Update replaces the value at p with the result of fn. fn runs without the
+// lock held and is retried when another writer changed the map meanwhile, so
+// it may read the SyncMap but should not have side effects.
+func (s *SyncMap) Update(p string, fn func(old any) any) (value any, err error) {
+	for {
+		s.mu.RLock()
+		old := cloneValue(s.m.get(p).Value())
+		version := s.version
+		s.mu.RUnlock()
+
+		value = fn(old)
+
+		s.mu.Lock()
+		if s.version != version {
+			s.mu.Unlock()
+			continue
+		}
+		if err = s.m.set(p, cloneValue(value)); err == nil {
+			s.version++
+		}
+		s.mu.Unlock()
+		return
+	}
+}
+
+// LoadOrStore returns the existing value at p if present. Otherwise it stores
+// and returns value. loaded is true if the value was already there.
+func (s *SyncMap) LoadOrStore(p string, value any) (actual any, loaded bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r := s.m.get(p); r.Exists() {
+		return cloneValue(r.Value()), true, nil
+	}
+	if err = s.m.set(p, cloneValue(value)); err == nil {
+		s.version++
+	}
+	return value, false, err
+}
+
+// CompareAndSwap stores new at p if the current value deeply equals old.
+// A missing path never matches.
+func (s *SyncMap) CompareAndSwap(p string, old, new any) (swapped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.m.get(p)
+	if !r.Exists() || !reflect.DeepEqual(r.Value(), old) {
+		return false
+	}
+	if s.m.set(p, cloneValue(new)) == nil {
+		s.version++
+		swapped = true
+	}
+	return
+}
+
+func (s *SyncMap) Length() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.m)
+}
+
+// Keys returns the top level keys in sorted order.
+func (s *SyncMap) Keys() []string {
+	s.mu.RLock()
+	keys := MapKeys(s.m)
+	s.mu.RUnlock()
+	sort.Strings(keys)
+	return keys
+}
+
+// ForEach calls cb for every top level key in sorted order. The callback
+// receives values from a snapshot taken before the first call.
+func (s *SyncMap) ForEach(cb func(int, string, any)) {
+	snap := s.Snapshot()
+	keys := MapKeys(snap)
+	sort.Strings(keys)
+	for x, k := range keys {
+		cb(x, k, snap[k])
+	}
+}
+
+// Snapshot returns a deep copy of the current content.
+func (s *SyncMap) Snapshot() Map {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Clone()
+}
+
+func (s *SyncMap) Bytes() RawValue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.m.Bytes()
+}
@@ -0,0 +1,75 @@
+package godao
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSyncMapUpdate(t *testing.T) {
+	s := NewSyncMap()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Update("stats.count", func(old any) any {
+				n, _ := old.(int)
+				return n + 1
+			})
+		}()
+	}
+	wg.Wait()
+	if got := s.Get("stats.count").Value(); got != 50 {
+		t.Errorf("count = %v, want 50", got)
+	}
+}
+
+func TestSyncMapCallbacks(t *testing.T) {
+	s := NewSyncMap(Map{"a": 1, "b": 2})
+	s.ForEach(func(i int, k string, v any) {
+		s.Set("seen."+k, v)
+		s.Get("seen." + k)
+	})
+	if !s.Has("seen.a") || !s.Has("seen.b") {
+		t.Error("writes from ForEach were lost")
+	}
+
+	if v, loaded, _ := s.LoadOrStore("a", 10); !loaded || v != 1 {
+		t.Errorf("LoadOrStore existing = %v, %v", v, loaded)
+	}
+	if v, loaded, _ := s.LoadOrStore("c", 3); loaded || v != 3 {
+		t.Errorf("LoadOrStore new = %v, %v", v, loaded)
+	}
+	if s.CompareAndSwap("a", 2, 5) {
+		t.Error("swapped on mismatch")
+	}
+	if !s.CompareAndSwap("a", 1, 5) || s.Get("a").Value() != 5 {
+		t.Error("swap failed")
+	}
+}
+
+func TestSyncMapCopies(t *testing.T) {
+	src := Map{"x": 1}
+	s := NewSyncMap()
+	s.Set("obj", src)
+	src["x"] = 2
+	s.Get("obj").Value().(Map)["x"] = 3
+	if x := s.Get("obj.x").Value(); x != 1 {
+		t.Errorf("obj.x = %v, want 1", x)
+	}
+	s.Update("list", func(old any) any {
+		return []any{Map{"y": 1}}
+	})
+	s.Update("obj", func(old any) any {
+		old.(Map)["x"] = 4
+		return old
+	})
+	v, _, _ := s.LoadOrStore("list", nil)
+	v.([]any)[0].(Map)["y"] = 2
+	if x := s.Get("obj.x").Value(); x != 4 {
+		t.Errorf("obj.x = %v, want 4", x)
+	}
+	if y := s.Get("list.0.y").Value(); y != 1 {
+		t.Errorf("list.0.y = %v, want 1", y)
+	}
+}
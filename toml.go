@@ -0,0 +1,37 @@
+package godao
+
+import "github.com/hyprstereo/go-dao/encoding/toml"
+
+func TomlEncode(v any) (data []byte, err error) {
+	return toml.Encode(v)
+}
+
+func TomlDecode(data []byte, v any) (err error) {
+	return toml.Decode(data, v)
+}
+
+// TOML encodes m as a TOML document.
+func (m Map) TOML() (data []byte, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return toml.Encode(map[string]any(m))
+}
+
+// FromTOML decodes data into m and returns it, a new Map is returned when m
+// is nil.
+func (m Map) FromTOML(data []byte) (mp Map, err error) {
+	var v map[string]any
+	if err = toml.Decode(data, &v); err != nil {
+		return
+	}
+	if m == nil {
+		m = make(Map, len(v))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for k, e := range v {
+		m[k] = e
+	}
+	mp = m
+	return
+}
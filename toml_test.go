@@ -0,0 +1,70 @@
+package godao
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/toml"
+)
+
+func TestTOML(t *testing.T) {
+	src := `
+title = "svc"
+owner.name = "jo"
+point = { x = 1, y = 2.5 }
+created = 2022-01-02T03:04:05Z
+day = 2022-01-02
+
+[server]
+port = 8080
+
+[[servers]]
+name = "a"
+
+[[servers]]
+name = "b"
+`
+	m, err := Map(nil).FromTOML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("owner.name").String() != "jo" || m.Get("point.y").Float() != 2.5 ||
+		m.Get("server.port").Int() != 8080 || m.Get("servers.1.name").String() != "b" ||
+		m.Get("servers.#").Int() != 2 {
+		t.Errorf("FromTOML = %v", m)
+	}
+	if created, ok := m["created"].(time.Time); !ok || !created.Equal(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("created = %#v", m["created"])
+	}
+	if _, ok := m["day"].(time.Time); !ok {
+		t.Errorf("day = %#v", m["day"])
+	}
+
+	out, err := m.TOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Map{}.FromTOML(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out2, _ := again.TOML()
+	if string(out) != string(out2) {
+		t.Errorf("round trip is not stable:\n%s\n---\n%s", out, out2)
+	}
+
+	out, err = Map{"e": []any{1, nil}, "n": nil, "t": []Map{{"a": nil, "b": 2}}}.TOML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "e = [1]\n\n[[t]]\n  b = 2\n"; string(out) != want {
+		t.Errorf("nil values = %q, want %q", out, want)
+	}
+
+	_, err = Map{}.FromTOML([]byte("a = 1\nb = = 2\n"))
+	var te *toml.Error
+	if !errors.As(err, &te) || te.Line != 2 || te.Column != 5 {
+		t.Errorf("error = %#v", err)
+	}
+}
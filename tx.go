@@ -0,0 +1,186 @@
+package godao
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrConflict = errors.New("transaction conflict")
+	ErrTxDone   = errors.New("transaction already committed or rolled back")
+)
+
+// TxOp is an operation buffered by a Tx.
+type TxOp struct {
+	Op    string // "set", "del" or "merge"
+	Path  string
+	Value any
+	Merge []MergeOptions
+}
+
+type txBase struct {
+	value  any
+	exists bool
+}
+
+// Tx buffers changes to a Map until Commit. Every path a Tx reads or writes
+// is remembered with the value it had when first touched; Commit fails with
+// ErrConflict if another writer changed one of them in the meantime.
+type Tx struct {
+	m    Map
+	ops  []TxOp
+	base map[string]txBase
+	view Map
+	done bool
+}
+
+// Begin starts a transaction on m.
+func (m Map) Begin() *Tx {
+	return &Tx{m: m, base: map[string]txBase{}}
+}
+
+func (t *Tx) touch(p string) {
+	if _, ok := t.base[p]; ok {
+		return
+	}
+	r := t.m.Get(p)
+	t.base[p] = txBase{value: cloneValue(r.Value()), exists: r.Exists()}
+}
+
+func (t *Tx) push(op TxOp) *Tx {
+	if t.done {
+		return t
+	}
+	if op.Op == "merge" {
+		for k := range op.Value.(Map) {
+			t.touch(EscapePathKey(k))
+		}
+	} else {
+		t.touch(op.Path)
+	}
+	t.ops = append(t.ops, op)
+	if t.view != nil {
+		t.view.applyTx(op)
+	}
+	return t
+}
+
+func (t *Tx) Set(p string, value any) *Tx {
+	return t.push(TxOp{Op: "set", Path: p, Value: cloneValue(value)})
+}
+
+func (t *Tx) Del(p string) *Tx {
+	return t.push(TxOp{Op: "del", Path: p})
+}
+
+// Merge buffers a MergeDeep of src.
+func (t *Tx) Merge(src Map, opts ...MergeOptions) *Tx {
+	return t.push(TxOp{Op: "merge", Value: src.Clone(), Merge: opts})
+}
+
+// Get reads p as it will be after Commit.
+func (t *Tx) Get(p string, defaultValue ...any) Result {
+	t.touch(p)
+	if t.view == nil {
+		mu.RLock()
+		t.view = t.m.Clone()
+		mu.RUnlock()
+		for _, op := range t.ops {
+			t.view.applyTx(op)
+		}
+	}
+	return t.view.get(p, defaultValue...)
+}
+
+// Pending returns the buffered operations in order.
+func (t *Tx) Pending() []TxOp {
+	return append([]TxOp{}, t.ops...)
+}
+
+// Rollback discards the buffered operations.
+func (t *Tx) Rollback() {
+	t.ops, t.view, t.done = nil, nil, true
+}
+
+// Commit applies every buffered operation, or none of them when one fails
+// or a touched path was changed by another writer.
+func (t *Tx) Commit() (err error) {
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	mu.Lock()
+	defer mu.Unlock()
+	for p, b := range t.base {
+		r := t.m.get(p)
+		if r.Exists() != b.exists || !jsonEqual(r.Value(), b.value) {
+			return fmt.Errorf("%w: %s was modified", ErrConflict, p)
+		}
+	}
+
+	// only the top level keys touched by the transaction are copied
+	keys, all := t.topKeys()
+	work := Map{}
+	if all {
+		work = t.m.Clone()
+	} else {
+		for k := range keys {
+			if v, ok := t.m[k]; ok {
+				work[k] = cloneValue(v)
+			}
+		}
+	}
+	for x, op := range t.ops {
+		if err = work.applyTx(op); err != nil {
+			return fmt.Errorf("tx op %d (%s %s): %w", x, op.Op, op.Path, err)
+		}
+	}
+	if all {
+		for k := range t.m {
+			keys[k] = true
+		}
+		for k := range work {
+			keys[k] = true
+		}
+	}
+	for k := range keys {
+		if v, ok := work[k]; ok {
+			t.m[k] = v
+		} else {
+			delete(t.m, k)
+		}
+	}
+	return
+}
+
+// topKeys returns the top level keys the operations write to. all is true
+// when a path cannot be reduced to a single key.
+func (t *Tx) topKeys() (keys map[string]bool, all bool) {
+	keys = map[string]bool{}
+	for _, op := range t.ops {
+		if op.Op == "merge" {
+			for k := range op.Value.(Map) {
+				keys[k] = true
+			}
+			continue
+		}
+		segs, ok := parsePath(op.Path)
+		if !ok || segs[0].wild {
+			return keys, true
+		}
+		keys[segs[0].key] = true
+	}
+	return
+}
+
+func (m Map) applyTx(op TxOp) (err error) {
+	switch op.Op {
+	case "set":
+		err = m.set(op.Path, cloneValue(op.Value))
+	case "del":
+		m.del(op.Path)
+	case "merge":
+		m.MergeDeep(op.Value.(Map), op.Merge...)
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package godao
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxCommitRollback(t *testing.T) {
+	m := Map{"db": Map{"host": "a", "port": 1}, "name": "svc", "tags": []any{"a"}}
+
+	tx := m.Begin()
+	tx.Set("db.host", "b").Del("name").Merge(Map{"extra": Map{"on": true}})
+	if got := tx.Get("db.host").Value(); got != "b" {
+		t.Errorf("tx view = %v", got)
+	}
+	if m.Get("db.host").Value() != "a" || len(tx.Pending()) != 3 {
+		t.Error("changes leaked before commit")
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("db.host").Value() != "b" || m.Has("name") || !m.Get("extra.on").Bool() {
+		t.Errorf("after commit = %v", m)
+	}
+	if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+		t.Errorf("second commit = %v", err)
+	}
+
+	tx = m.Begin()
+	tx.Set("db.port", 2)
+	tx.Rollback()
+	if m.Get("db.port").Value() != 1 {
+		t.Error("rollback applied changes")
+	}
+
+	tx = m.Begin()
+	tx.Set("db.port", 3).Set("tags.x", 1)
+	if err := tx.Commit(); err == nil {
+		t.Error("expected set error")
+	}
+	if m.Get("db.port").Value() != 1 {
+		t.Error("failed commit applied changes")
+	}
+}
+
+func TestTxConflict(t *testing.T) {
+	m := Map{"count": 1}
+	tx := m.Begin()
+	n := tx.Get("count").Int()
+	tx.Set("count", n+1)
+	m.Set("count", 10)
+	if err := tx.Commit(); !errors.Is(err, ErrConflict) {
+		t.Errorf("commit = %v", err)
+	}
+	if m.Get("count").Value() != 10 {
+		t.Error("conflicting commit applied")
+	}
+}
@@ -0,0 +1,266 @@
+package godao
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/hjson"
+	"github.com/hyprstereo/go-dao/encoding/json"
+	"github.com/spf13/afero"
+)
+
+// WatchOptions configures a Watcher.
+type WatchOptions struct {
+	// Fs holds the file, defaults to the OS file system.
+	Fs afero.Fs
+	// Interval between two polls, defaults to 1s.
+	Interval time.Duration
+	// Format is "json" or "hjson", by default ".json" files are JSON and
+	// the others HJSON.
+	Format string
+}
+
+// WatchEvent is sent to the subscribers of a Watcher. Map is the current
+// content, Changed the paths whose value changed, were added or removed. When
+// the file cannot be read or decoded Err is set and Map is the last good
+// version.
+type WatchEvent struct {
+	Map     Map
+	Changed []string
+	Err     error
+}
+
+// Watcher polls a JSON or HJSON file and reloads it when it changes. Polling
+// works on any afero.Fs, MemMapFs included.
+type Watcher struct {
+	path    string
+	opt     WatchOptions
+	mu      sync.RWMutex
+	current Map
+	err     error
+	data    []byte
+	mod     time.Time
+	size    int64
+	subs    map[int]func(WatchEvent)
+	next    int
+	check   sync.Mutex
+	stop    chan struct{}
+	once    sync.Once
+	done    chan struct{}
+	// notifying is set while the polling goroutine runs the subscribers
+	notifying int32
+}
+
+// NewWatcher loads path and starts polling it, Close stops it.
+func NewWatcher(path string, opts ...WatchOptions) (w *Watcher, err error) {
+	w = &Watcher{path: path, subs: map[int]func(WatchEvent){}, stop: make(chan struct{}), done: make(chan struct{})}
+	if len(opts) > 0 {
+		w.opt = opts[0]
+	}
+	if w.opt.Fs == nil {
+		w.opt.Fs = afero.NewOsFs()
+	}
+	if w.opt.Interval <= 0 {
+		w.opt.Interval = time.Second
+	}
+	if w.opt.Format == "" {
+		w.opt.Format = "hjson"
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			w.opt.Format = "json"
+		}
+	}
+	if _, err = w.Check(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return
+}
+
+func (w *Watcher) run() {
+	defer close(w.done)
+	t := time.NewTicker(w.opt.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-t.C:
+			w.poll(true)
+		}
+	}
+}
+
+// Close stops polling. Called from a subscriber, it returns without waiting
+// for the polling goroutine.
+func (w *Watcher) Close() {
+	w.once.Do(func() { close(w.stop) })
+	if atomic.LoadInt32(&w.notifying) == 0 {
+		<-w.done
+	}
+}
+
+// Map returns a copy of the last good version.
+func (w *Watcher) Map() Map {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current.Clone()
+}
+
+// Get reads path p of the last good version, see Map.Get.
+func (w *Watcher) Get(p string, defaultValue ...any) Result {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current.Get(p, defaultValue...)
+}
+
+// Err returns the error of the last reload, nil once the file is valid again.
+func (w *Watcher) Err() error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.err
+}
+
+// Subscribe registers fn for the reloads and the errors, and returns a
+// function removing the subscription. fn runs on the polling goroutine, or
+// on the caller of Check, and may call Check or Close.
+func (w *Watcher) Subscribe(fn func(WatchEvent)) (unsubscribe func()) {
+	w.mu.Lock()
+	id := w.next
+	w.next++
+	w.subs[id] = fn
+	w.mu.Unlock()
+	return func() {
+		w.mu.Lock()
+		delete(w.subs, id)
+		w.mu.Unlock()
+	}
+}
+
+// Check polls the file once and returns the changed paths. It is called by
+// the polling goroutine and can be called directly to reload immediately.
+func (w *Watcher) Check() (changed []string, err error) {
+	return w.poll(false)
+}
+
+// poll reloads the file and notifies the subscribers once the reload is
+// over, so they can call Check.
+func (w *Watcher) poll(poller bool) (changed []string, err error) {
+	w.check.Lock()
+	ev, changed, err := w.reload()
+	w.check.Unlock()
+	if ev != nil {
+		if poller {
+			atomic.StoreInt32(&w.notifying, 1)
+			defer atomic.StoreInt32(&w.notifying, 0)
+		}
+		w.notify(*ev)
+	}
+	return
+}
+
+// reload reads the file and returns the event to send, if any.
+func (w *Watcher) reload() (ev *WatchEvent, changed []string, err error) {
+	fi, err := w.opt.Fs.Stat(w.path)
+	if err != nil {
+		if w.data != nil || w.current == nil {
+			// report a missing file once, and reload it when it comes back
+			w.data, w.mod, w.size = nil, time.Time{}, 0
+			ev = w.fail(err)
+		}
+		return
+	}
+	if w.current != nil && fi.ModTime().Equal(w.mod) && fi.Size() == w.size {
+		return
+	}
+	data, err := afero.ReadFile(w.opt.Fs, w.path)
+	if err != nil {
+		// retried on every poll, but reported once until the error changes
+		if w.err == nil || w.err.Error() != err.Error() {
+			ev = w.fail(err)
+		}
+		return
+	}
+	// only once read, so a failed read is retried on the next poll
+	w.mod, w.size = fi.ModTime(), fi.Size()
+	if w.data != nil && bytes.Equal(data, w.data) {
+		return
+	}
+	w.data = data
+	m := Map{}
+	if w.opt.Format == "json" {
+		err = json.Decode(data, &m)
+	} else {
+		err = hjson.Unmarshal(data, &m)
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %w", w.path, err)
+		ev = w.fail(err)
+		return
+	}
+	if m == nil {
+		m = Map{}
+	}
+
+	w.mu.Lock()
+	old, failed := w.current, w.err != nil
+	changed = changedPaths(old, m)
+	w.current, w.err = m, nil
+	w.mu.Unlock()
+	// a recovery is reported even without changes, to clear the error
+	if old != nil && (len(changed) > 0 || failed) {
+		ev = &WatchEvent{Map: m.Clone(), Changed: changed}
+	}
+	return
+}
+
+// fail records err and returns the event reporting it.
+func (w *Watcher) fail(err error) *WatchEvent {
+	w.mu.Lock()
+	w.err = err
+	w.mu.Unlock()
+	return &WatchEvent{Map: w.Map(), Err: err}
+}
+
+func (w *Watcher) notify(ev WatchEvent) {
+	w.mu.RLock()
+	ids := MapKeys(w.subs)
+	sort.Ints(ids)
+	subs := make([]func(WatchEvent), 0, len(ids))
+	for _, id := range ids {
+		subs = append(subs, w.subs[id])
+	}
+	w.mu.RUnlock()
+	for _, fn := range subs {
+		fn(ev)
+	}
+}
+
+// changedPaths lists the paths whose value differs between old and new, see
+// collectChanges.
+func changedPaths(old, new Map) (paths []string) {
+	keys := map[string]bool{}
+	for k := range old {
+		keys[k] = true
+	}
+	for k := range new {
+		keys[k] = true
+	}
+	sorted := MapKeys(keys)
+	sort.Strings(sorted)
+	var changes []Change
+	for _, k := range sorted {
+		ov, oe := old[k]
+		nv, ne := new[k]
+		collectChanges("reload", EscapePathKey(k), ov, nv, oe, ne, false, &changes)
+	}
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	return
+}
@@ -0,0 +1,170 @@
+package godao
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+func TestWatcher(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/app.hjson", []byte("{\n  name: dao\n  db: {\n    port: 5432\n  }\n}"), 0644)
+	w, err := NewWatcher("/app.hjson", WatchOptions{Fs: fs, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	var events []WatchEvent
+	w.Subscribe(func(ev WatchEvent) { events = append(events, ev) })
+
+	afero.WriteFile(fs, "/app.hjson", []byte("{\n  name: dao\n  db: {\n    port: 6543\n    user: svc\n  }\n  debug: true\n}"), 0644)
+	changed, err := w.Check()
+	if err != nil || strings.Join(changed, ",") != "db.port,db.user,debug" {
+		t.Errorf("Check = %v, %v", changed, err)
+	}
+	if len(events) != 1 || events[0].Map.Get("db.port").Int() != 6543 {
+		t.Errorf("events = %v", events)
+	}
+
+	afero.WriteFile(fs, "/app.hjson", []byte("{ name: "), 0644)
+	if _, err = w.Check(); err == nil || w.Err() == nil {
+		t.Error("invalid file should fail")
+	}
+	if w.Get("db.port").Int() != 6543 || len(events) != 2 || events[1].Err == nil || events[1].Map.Get("debug").Bool() != true {
+		t.Errorf("last good version lost: %v, %v", w.Map(), events)
+	}
+	w.Check()
+	if len(events) != 2 {
+		t.Error("an unchanged invalid file should be reported once")
+	}
+
+	afero.WriteFile(fs, "/app.hjson", []byte("{\n  name: dao\n  db: {\n    port: 6543\n    user: svc\n  }\n  debug: true\n}"), 0644)
+	if changed, err = w.Check(); err != nil || len(changed) != 0 || w.Err() != nil || len(events) != 3 {
+		t.Errorf("recovery = %v, %v, %d events", changed, err, len(events))
+	}
+
+	fs.Remove("/app.hjson")
+	w.Check()
+	w.Check()
+	if len(events) != 4 || events[3].Err == nil {
+		t.Errorf("removal events = %v", events)
+	}
+
+	if _, err = NewWatcher("/missing.json", WatchOptions{Fs: fs}); err == nil {
+		t.Error("missing file should fail")
+	}
+}
+
+func TestWatcherPolling(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": 1}`), 0644)
+	w, err := NewWatcher("/app.json", WatchOptions{Fs: fs, Interval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(chan []string, 1)
+	w.Subscribe(func(ev WatchEvent) { got <- ev.Changed })
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": 2}`), 0644)
+	select {
+	case changed := <-got:
+		if strings.Join(changed, ",") != "n" {
+			t.Errorf("changed = %v", changed)
+		}
+	case <-time.After(time.Second):
+		t.Error("no reload")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.Close()
+		}()
+	}
+	wg.Wait()
+	if w.Get("n").Int() != 2 {
+		t.Errorf("n = %v", w.Get("n"))
+	}
+}
+
+// failingFs fails to open files while fail is set.
+type failingFs struct {
+	afero.Fs
+	fail bool
+}
+
+func (f *failingFs) Open(name string) (afero.File, error) {
+	if f.fail {
+		return nil, errors.New("read failed")
+	}
+	return f.Fs.Open(name)
+}
+
+func (f *failingFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if f.fail {
+		return nil, errors.New("read failed")
+	}
+	return f.Fs.OpenFile(name, flag, perm)
+}
+
+func TestWatcherReadError(t *testing.T) {
+	fs := &failingFs{Fs: afero.NewMemMapFs()}
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": 1}`), 0644)
+	w, err := NewWatcher("/app.json", WatchOptions{Fs: fs, Interval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	errs := 0
+	w.Subscribe(func(ev WatchEvent) {
+		if ev.Err != nil {
+			errs++
+		}
+	})
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": 22}`), 0644)
+	fs.fail = true
+	if _, err = w.Check(); err == nil {
+		t.Error("read error should be reported")
+	}
+	w.Check()
+	if errs != 1 {
+		t.Errorf("a persistent read error was reported %d times", errs)
+	}
+	fs.fail = false
+	if changed, err := w.Check(); err != nil || len(changed) != 1 || w.Get("n").Int() != 22 || w.Err() != nil {
+		t.Errorf("retry after a read error = %v, %v, n = %v", changed, err, w.Get("n"))
+	}
+}
+
+func TestWatcherCloseFromSubscriber(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": 1}`), 0644)
+	w, err := NewWatcher("/app.json", WatchOptions{Fs: fs, Interval: 5 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := make(chan struct{})
+	w.Subscribe(func(ev WatchEvent) {
+		if ev.Err != nil {
+			w.Check()
+			w.Close()
+			close(closed)
+		}
+	})
+	afero.WriteFile(fs, "/app.json", []byte(`{"n": `), 0644)
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Check or Close from a subscriber blocked")
+	}
+	select {
+	case <-w.done:
+	case <-time.After(time.Second):
+		t.Error("polling did not stop")
+	}
+}
@@ -0,0 +1,29 @@
+package godao
+
+import "github.com/hyprstereo/go-dao/encoding/xml"
+
+// XML encodes m as an XML document, see xml.Encode for the conventions.
+func (m Map) XML(opts ...xml.Options) (data []byte, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return xml.Encode(map[string]any(m), opts...)
+}
+
+// FromXML decodes an XML document into m, keyed by its root element, and
+// returns it. A new Map is returned when m is nil.
+func (m Map) FromXML(data []byte, opts ...xml.Options) (mp Map, err error) {
+	v, err := xml.Decode(data, opts...)
+	if err != nil {
+		return
+	}
+	if m == nil {
+		m = make(Map, len(v))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for k, e := range v {
+		m[k] = e
+	}
+	mp = m
+	return
+}
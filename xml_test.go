@@ -0,0 +1,88 @@
+package godao
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hyprstereo/go-dao/encoding/xml"
+)
+
+func TestXML(t *testing.T) {
+	src := `<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/">
+  <title>News &amp; more</title>
+  <entry id="1"><name>a</name><media:thumb url="x.png"/></entry>
+  <entry id="2"><name>b</name>text</entry>
+</feed>`
+	m, err := Map(nil).FromXML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("feed.title").String() != "News & more" || m.Get("feed.entry.#").Int() != 2 ||
+		m.Get("feed.entry.0.@id").String() != "1" || m.Get("feed.entry.0.thumb.@url").String() != "x.png" ||
+		m.Get("feed.entry.1.#text").String() != "text" || m.Has("feed.@xmlns") {
+		t.Errorf("FromXML = %v", m)
+	}
+
+	ns, err := Map{}.FromXML([]byte(src), xml.Options{Namespaces: true, ForceArray: []string{"title"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ns.Get("feed.entry.0").Value().(map[string]any)["media:thumb"]; !ok ||
+		ns.Get("feed.@xmlns:media").String() != "http://search.yahoo.com/mrss/" || ns.Get("feed.title.0").String() != "News & more" {
+		t.Errorf("FromXML(namespaces) = %v", ns)
+	}
+
+	out, err := Map{"item": Map{"@id": 1, "name": `a<b>"`, "tag": []any{"x", "y"}, "#text": "t"}}.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `<item id="1">t<name>a&lt;b&gt;&#34;</name><tag>x</tag><tag>y</tag></item>`; string(out) != want {
+		t.Errorf("XML = %s, want %s", out, want)
+	}
+	back, _ := Map{}.FromXML(out)
+	if !reflect.DeepEqual(back, Map{"item": map[string]any{"@id": "1", "name": `a<b>"`, "tag": []any{"x", "y"}, "#text": "t"}}) {
+		t.Errorf("round trip = %v", back)
+	}
+
+	if dom := (Map{"href": `a"b`}).Dom("a", "link"); dom != `<a href="a&#34;b">link</a>` {
+		t.Errorf("Dom = %s", dom)
+	}
+
+	for _, bad := range []Map{{"2bad key": 1}, {"a": Map{"b c": 1}}, {"a": Map{"@x y": 1}}, {"a": Map{"@": 1}}} {
+		if _, err := bad.XML(); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Errorf("XML(%v) = %v, want an invalid name error", bad, err)
+		}
+	}
+}
+
+func TestXMLStream(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("<feed><meta><entry>skip</entry></meta>")
+	for i := 0; i < 3; i++ {
+		b.WriteString(`<entry n="` + string(rune('0'+i)) + `"><v>x</v></entry>`)
+	}
+	b.WriteString("</feed>")
+	d := xml.NewDecoder(strings.NewReader(b.String()), "feed/entry")
+	var got []string
+	for {
+		rec, err := d.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, Map(rec).Get("@n").String())
+	}
+	if strings.Join(got, ",") != "0,1,2" {
+		t.Errorf("records = %v", got)
+	}
+
+	d = xml.NewDecoder(strings.NewReader("<a><b></a>"), "b")
+	if _, err := d.Next(); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Errorf("mismatched tags error = %v", err)
+	}
+}
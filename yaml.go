@@ -0,0 +1,58 @@
+package godao
+
+import (
+	"fmt"
+
+	"github.com/hyprstereo/go-dao/encoding/yaml"
+)
+
+func YamlEncode(v any, flow ...bool) (data []byte, err error) {
+	return yaml.Encode(v, flow...)
+}
+
+func YamlDecode(data []byte, v any) (err error) {
+	return yaml.Decode(data, v)
+}
+
+// YAML encodes m as a YAML document, in flow style when flow is set.
+func (m Map) YAML(flow ...bool) (data []byte, err error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return yaml.Encode(map[string]any(m), flow...)
+}
+
+// FromYAML decodes the first document of data into m and returns it, a new
+// Map is returned when m is nil.
+func (m Map) FromYAML(data []byte) (mp Map, err error) {
+	var v map[string]any
+	if err = yaml.Decode(data, &v); err != nil {
+		return
+	}
+	if m == nil {
+		m = make(Map, len(v))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for k, e := range v {
+		m[k] = e
+	}
+	mp = m
+	return
+}
+
+// MapsFromYAML decodes every document of a multi-document stream, each
+// document must be a mapping.
+func MapsFromYAML(data []byte) (maps []Map, err error) {
+	docs, err := yaml.DecodeAll(data)
+	if err != nil {
+		return
+	}
+	for x, d := range docs {
+		obj, ok := d.(map[string]any)
+		if !ok && d != nil {
+			return nil, fmt.Errorf("yaml: document %d is a %T, not a mapping", x+1, d)
+		}
+		maps = append(maps, Map(obj))
+	}
+	return
+}
@@ -0,0 +1,87 @@
+package godao
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyprstereo/go-dao/encoding/yaml"
+)
+
+func TestYAML(t *testing.T) {
+	src := `
+defaults: &defaults
+  adapter: postgres
+  pool: 5
+dev:
+  <<: *defaults
+  pool: 10
+  hosts: [a, "b"]
+  flags: {debug: true}
+list:
+  - name: x
+    on: yes
+`
+	m, err := Map(nil).FromYAML([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Get("dev.adapter").String() != "postgres" || m.Get("dev.pool").Int() != 10 ||
+		m.Get("dev.hosts.1").String() != "b" || !m.Get("dev.flags.debug").Bool() ||
+		m.Get("list.0.name").String() != "x" {
+		t.Errorf("FromYAML = %v", m)
+	}
+
+	out, err := Map{"b": []any{1, "x"}, "a": Map{"c": true}}.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a:\n  c: true\nb:\n  - 1\n  - x\n"; string(out) != want {
+		t.Errorf("YAML = %q, want %q", out, want)
+	}
+	out, _ = Map{"b": []any{1, "x"}, "a": Map{"c": true}}.YAML(true)
+	if want := "{a: {c: true}, b: [1, x]}\n"; string(out) != want {
+		t.Errorf("YAML(flow) = %q, want %q", out, want)
+	}
+	back, err := Map{}.FromYAML(out)
+	if err != nil || !reflect.DeepEqual(back, Map{"a": map[string]any{"c": true}, "b": []any{1, "x"}}) {
+		t.Errorf("round trip = %v, %v", back, err)
+	}
+
+	maps, err := MapsFromYAML([]byte("a: 1\n---\na: 2\n"))
+	if err != nil || len(maps) != 2 || maps[1].Get("a").Int() != 2 {
+		t.Errorf("MapsFromYAML = %v, %v", maps, err)
+	}
+}
+
+func TestYAMLErrors(t *testing.T) {
+	var ye *yaml.Error
+	_, err := Map{}.FromYAML([]byte("a: 1\nb: [1, 2\n"))
+	if !errors.As(err, &ye) || ye.Line == 0 {
+		t.Errorf("syntax error = %#v", err)
+	}
+	_, err = Map{}.FromYAML([]byte("a: 1\nb:\n  c: 1\n  c: 2\n"))
+	if !errors.As(err, &ye) || ye.Line != 4 || ye.Column != 3 || !strings.Contains(err.Error(), "already defined at line 3") {
+		t.Errorf("duplicate key error = %v", err)
+	}
+	_, err = Map{}.FromYAML([]byte("a: &x\n  b: *x\n"))
+	if err == nil {
+		t.Error("recursive alias should fail")
+	}
+	bomb := "a: &a [x, x, x, x, x, x, x, x, x, x]\n"
+	for c := 'b'; c <= 'i'; c++ {
+		prev := string(c - 1)
+		bomb += string(c) + ": &" + string(c) + " [" + strings.Repeat("*"+prev+", ", 9) + "*" + prev + "]\n"
+	}
+	start := time.Now()
+	_, err = Map{}.FromYAML([]byte(bomb))
+	if err == nil || !strings.Contains(err.Error(), "excessive aliasing") || time.Since(start) > 5*time.Second {
+		t.Errorf("alias bomb = %v after %s", err, time.Since(start))
+	}
+	_, err = Map{}.FromYAML([]byte("- 1\n"))
+	if !errors.As(err, &ye) || ye.Line != 1 || ye.Column != 1 {
+		t.Errorf("non mapping error = %v", err)
+	}
+}